@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type DatabaseConfig struct {
 	Host     string `env:"DATABASE_HOST" yaml:"host" validate:"required"`
 	Port     int    `env:"DATABASE_PORT" yaml:"port" validate:"gt=0,lte=65535"`
@@ -13,7 +15,30 @@ type FiberConfig struct {
 	Port int `env:"FIBER_PORT" yaml:"port" validate:"gt=0,lte=65535"`
 }
 
+// ShippingConfig holds the carrier limits of a single shipment, zero means
+// no limit.
+type ShippingConfig struct {
+	MaxParcels     int `env:"SHIPPING_MAX_PARCELS" yaml:"max_parcels" env-default:"0" validate:"gte=0"`
+	MaxWeightGrams int `env:"SHIPPING_MAX_WEIGHT_GRAMS" yaml:"max_weight_grams" env-default:"0" validate:"gte=0"`
+}
+
+// OrdersConfig caps the items of an order at MaxItemsCount, zero meaning no
+// cap. Solving with tracked stock or by cost takes time and memory
+// proportional to the items count, unlike the unbounded solver, so lines
+// solved that way are capped lower at MaxBoundedItemsCount, zero meaning no
+// cap either. Idempotency keys of created orders are kept for
+// IdempotencyKeyTTL.
+type OrdersConfig struct {
+	DefaultStrategy      string         `env:"ORDERS_DEFAULT_STRATEGY" yaml:"default_strategy" env-default:"fewest-items" validate:"oneof=fewest-items fewest-packs greedy cheapest"`
+	HandlingCostCents    int            `env:"ORDERS_HANDLING_COST_CENTS" yaml:"handling_cost_cents" env-default:"0" validate:"gte=0"`
+	MaxItemsCount        int            `env:"ORDERS_MAX_ITEMS_COUNT" yaml:"max_items_count" env-default:"10000000" validate:"gte=0"`
+	MaxBoundedItemsCount int            `env:"ORDERS_MAX_BOUNDED_ITEMS_COUNT" yaml:"max_bounded_items_count" env-default:"1000000" validate:"gte=0"`
+	IdempotencyKeyTTL    time.Duration  `env:"ORDERS_IDEMPOTENCY_KEY_TTL" yaml:"idempotency_key_ttl" env-default:"24h" validate:"gt=0"`
+	Shipping             ShippingConfig `yaml:"shipping"`
+}
+
 type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 	Fiber    FiberConfig    `yaml:"fiber"`
+	Orders   OrdersConfig   `yaml:"orders"`
 }
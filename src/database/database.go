@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"time"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
@@ -12,10 +14,22 @@ import (
 	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
 )
 
+// Querier runs queries either straight on the connection pool or inside a
+// transaction started with WithTransaction.
+type Querier interface {
+	QueryWithScan(query string, dest interface{}, args ...any) error
+	Exec(query string, args ...any) error
+}
+
 type Database struct {
 	connection *pgxpool.Pool
 }
 
+// Tx is a Querier bound to an open transaction.
+type Tx struct {
+	tx pgx.Tx
+}
+
 func NewDatabase(cfg config.DatabaseConfig) (*Database, error) {
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
 	fmt.Println("Database DSN:", dsn) // For demonstration purposes
@@ -30,7 +44,7 @@ func NewDatabase(cfg config.DatabaseConfig) (*Database, error) {
 	}, err
 }
 
-func (c *Database) scanValue(rows pgx.Rows, dest interface{}) error {
+func scanValue(rows pgx.Rows, dest interface{}) error {
 	valueKind := reflect.ValueOf(dest).Elem().Kind()
 
 	switch valueKind {
@@ -41,17 +55,26 @@ func (c *Database) scanValue(rows pgx.Rows, dest interface{}) error {
 	}
 }
 
-func (db *Database) QueryWithScan(query string, dest interface{}, args ...any) error {
+func queryWithScan(ctx context.Context, query string, dest interface{}, args []any, run func(context.Context, string, ...any) (pgx.Rows, error)) error {
 	if reflect.ValueOf(dest).Kind() != reflect.Ptr {
 		return errors.New("destination should be pointer or else changes won't reflect on it")
 	}
 
-	rows, err := db.connection.Query(context.Background(), query, args...)
+	rows, err := run(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 
-	return db.scanValue(rows, dest)
+	return scanValue(rows, dest)
+}
+
+func (db *Database) QueryWithScan(query string, dest interface{}, args ...any) error {
+	return queryWithScan(context.Background(), query, dest, args, db.connection.Query)
+}
+
+func (db *Database) Exec(query string, args ...any) error {
+	_, err := db.connection.Exec(context.Background(), query, args...)
+	return err
 }
 
 func (db *Database) Query(query string) error {
@@ -59,6 +82,79 @@ func (db *Database) Query(query string) error {
 	return err
 }
 
+// WithTransaction runs fn inside a transaction that is committed when fn
+// returns nil and rolled back otherwise.
+func (db *Database) WithTransaction(fn func(tx Querier) error) error {
+	return pgx.BeginFunc(context.Background(), db.connection, func(tx pgx.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+func (t *Tx) QueryWithScan(query string, dest interface{}, args ...any) error {
+	return queryWithScan(context.Background(), query, dest, args, t.tx.Query)
+}
+
+func (t *Tx) Exec(query string, args ...any) error {
+	_, err := t.tx.Exec(context.Background(), query, args...)
+	return err
+}
+
+// Notify sends payload to every session listening on the channel. Sent
+// through a transaction, it's only delivered once the transaction commits
+// and dropped if it's rolled back; sent on the pool, it's delivered right
+// away.
+func Notify(q Querier, channel, payload string) error {
+	return q.Exec("SELECT pg_notify($1, $2)", channel, payload)
+}
+
+// Listen calls handle with the payload of every notification sent to the
+// channel until ctx is done. It listens on a connection of its own, taken
+// out of the pool, and connects again a second after losing it. Whatever
+// is sent while disconnected is missed, so onConnect is called every time
+// listening starts for the caller to catch up.
+func (db *Database) Listen(ctx context.Context, channel string, onConnect func(), handle func(payload string)) error {
+	for {
+		err := db.listen(ctx, channel, onConnect, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		slog.Warn("Lost the connection listening for notifications", "channel", channel, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (db *Database) listen(ctx context.Context, channel string, onConnect func(), handle func(payload string)) error {
+	pooled, err := db.connection.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection stays subscribed, so it's never handed back to the pool
+	connection := pooled.Hijack()
+	defer connection.Close(context.Background())
+
+	if _, err := connection.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	onConnect()
+
+	for {
+		notification, err := connection.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		handle(notification.Payload)
+	}
+}
+
 func (db *Database) Close() {
 	db.connection.Close()
 }
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ALTER COLUMN pack_setup TYPE TEXT;
+ALTER TABLE orders ADD COLUMN packs JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Back-fill the structured breakdown by parsing the legacy "2x500, 1x1000" format
+UPDATE orders o
+SET packs = COALESCE((
+    SELECT jsonb_agg(
+        jsonb_build_object(
+            'pack_size', split_part(line, 'x', 2)::int,
+            'quantity', split_part(line, 'x', 1)::int
+        )
+        ORDER BY split_part(line, 'x', 2)::int DESC
+    )
+    FROM regexp_split_to_table(btrim(o.pack_setup), '\s*,\s*') AS line
+    WHERE line <> ''
+), '[]'::jsonb);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN packs;
+ALTER TABLE orders ALTER COLUMN pack_setup TYPE VARCHAR(255);
+-- +goose StatementEnd
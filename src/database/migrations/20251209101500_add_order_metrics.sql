@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN shipped_items INT NOT NULL DEFAULT 0,
+    ADD COLUMN overshoot INT NOT NULL DEFAULT 0,
+    ADD COLUMN total_packs INT NOT NULL DEFAULT 0;
+
+UPDATE orders o
+SET shipped_items = totals.shipped_items,
+    total_packs = totals.total_packs
+FROM (
+    SELECT
+        id,
+        COALESCE(SUM((pack->>'pack_size')::int * (pack->>'quantity')::int), 0) AS shipped_items,
+        COALESCE(SUM((pack->>'quantity')::int), 0) AS total_packs
+    FROM orders
+    LEFT JOIN LATERAL jsonb_array_elements(packs) AS pack ON TRUE
+    GROUP BY id
+) AS totals
+WHERE o.id = totals.id;
+
+UPDATE orders SET overshoot = GREATEST(shipped_items - items_count, 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+    DROP COLUMN shipped_items,
+    DROP COLUMN overshoot,
+    DROP COLUMN total_packs;
+-- +goose StatementEnd
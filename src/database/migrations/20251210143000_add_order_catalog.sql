@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Orders created before this migration have no recorded catalog
+ALTER TABLE orders ADD COLUMN catalog JSONB NOT NULL DEFAULT '[]'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN catalog;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE pack_size_catalogs (
+    id UUID NOT NULL PRIMARY KEY,
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    pack_sizes JSONB NOT NULL DEFAULT '[]'::jsonb
+);
+
+CREATE INDEX pack_size_catalogs_effective_from_idx ON pack_size_catalogs (effective_from);
+
+-- The current pack sizes become the first catalog version
+INSERT INTO pack_size_catalogs (id, effective_from, pack_sizes)
+SELECT
+    gen_random_uuid(),
+    now(),
+    COALESCE(jsonb_agg(jsonb_build_object('id', id, 'size', size) ORDER BY size), '[]'::jsonb)
+FROM pack_sizes;
+
+ALTER TABLE orders ADD COLUMN catalog_id UUID REFERENCES pack_size_catalogs (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN catalog_id;
+DROP TABLE pack_size_catalogs;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE products (
+    id UUID NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Everything created before products existed belongs to the default product
+INSERT INTO products (id, name, sku) VALUES ('00000000-0000-0000-0000-000000000001', 'Default', 'DEFAULT');
+
+ALTER TABLE pack_sizes
+    ADD COLUMN product_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES products (id) ON DELETE CASCADE,
+    DROP CONSTRAINT pack_sizes_size_key,
+    ADD CONSTRAINT pack_sizes_product_id_size_key UNIQUE (product_id, size);
+ALTER TABLE pack_sizes ALTER COLUMN product_id DROP DEFAULT;
+
+ALTER TABLE pack_size_catalogs
+    ADD COLUMN product_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE pack_size_catalogs ALTER COLUMN product_id DROP DEFAULT;
+
+DROP INDEX pack_size_catalogs_effective_from_idx;
+CREATE INDEX pack_size_catalogs_product_id_effective_from_idx ON pack_size_catalogs (product_id, effective_from);
+
+ALTER TABLE orders
+    ADD COLUMN product_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES products (id);
+ALTER TABLE orders ALTER COLUMN product_id DROP DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN product_id;
+
+DROP INDEX pack_size_catalogs_product_id_effective_from_idx;
+ALTER TABLE pack_size_catalogs DROP COLUMN product_id;
+CREATE INDEX pack_size_catalogs_effective_from_idx ON pack_size_catalogs (effective_from);
+
+ALTER TABLE pack_sizes
+    DROP CONSTRAINT pack_sizes_product_id_size_key,
+    DROP COLUMN product_id,
+    ADD CONSTRAINT pack_sizes_size_key UNIQUE (size);
+
+DROP TABLE products;
+-- +goose StatementEnd
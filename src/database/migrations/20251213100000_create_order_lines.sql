@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE order_lines (
+    id UUID NOT NULL PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    line_number INT NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id),
+    items_count INT NOT NULL,
+    pack_setup TEXT NOT NULL,
+    packs JSONB NOT NULL DEFAULT '[]'::jsonb,
+    shipped_items INT NOT NULL DEFAULT 0,
+    overshoot INT NOT NULL DEFAULT 0,
+    total_packs INT NOT NULL DEFAULT 0,
+    catalog JSONB NOT NULL DEFAULT '[]'::jsonb,
+    catalog_id UUID REFERENCES pack_size_catalogs (id),
+    UNIQUE (order_id, line_number)
+);
+
+-- Every existing order becomes a single line order
+INSERT INTO order_lines (id, order_id, line_number, product_id, items_count, pack_setup, packs, shipped_items, overshoot, total_packs, catalog, catalog_id)
+SELECT gen_random_uuid(), id, 1, product_id, items_count, pack_setup, packs, shipped_items, overshoot, total_packs, catalog, catalog_id
+FROM orders;
+
+-- Orders covering several products have no product of their own
+ALTER TABLE orders ALTER COLUMN product_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM orders WHERE product_id IS NULL;
+ALTER TABLE orders ALTER COLUMN product_id SET NOT NULL;
+
+DROP TABLE order_lines;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A NULL stock means the pack size isn't tracked and is always available
+ALTER TABLE pack_sizes ADD COLUMN stock INT CHECK (stock >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pack_sizes DROP COLUMN stock;
+-- +goose StatementEnd
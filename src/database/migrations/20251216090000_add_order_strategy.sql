@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Orders created before strategies existed were packed with the default one
+ALTER TABLE orders ADD COLUMN strategy TEXT NOT NULL DEFAULT 'fewest-items';
+ALTER TABLE orders ALTER COLUMN strategy DROP DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN strategy;
+-- +goose StatementEnd
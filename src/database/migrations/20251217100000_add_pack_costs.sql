@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pack_sizes ADD COLUMN unit_cost_cents INT NOT NULL DEFAULT 0 CHECK (unit_cost_cents >= 0);
+
+ALTER TABLE order_lines ADD COLUMN total_cost_cents INT NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN handling_cost_cents INT NOT NULL DEFAULT 0,
+    ADD COLUMN total_cost_cents INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+    DROP COLUMN handling_cost_cents,
+    DROP COLUMN total_cost_cents;
+
+ALTER TABLE order_lines DROP COLUMN total_cost_cents;
+
+ALTER TABLE pack_sizes DROP COLUMN unit_cost_cents;
+-- +goose StatementEnd
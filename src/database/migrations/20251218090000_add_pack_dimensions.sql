@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pack_sizes
+    ADD COLUMN weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0),
+    ADD COLUMN length_mm INT NOT NULL DEFAULT 0 CHECK (length_mm >= 0),
+    ADD COLUMN width_mm INT NOT NULL DEFAULT 0 CHECK (width_mm >= 0),
+    ADD COLUMN height_mm INT NOT NULL DEFAULT 0 CHECK (height_mm >= 0);
+
+ALTER TABLE order_lines
+    ADD COLUMN total_weight_grams BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN total_volume_mm3 BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN total_weight_grams BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN total_volume_mm3 BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN shipments JSONB NOT NULL DEFAULT '[]'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+    DROP COLUMN total_weight_grams,
+    DROP COLUMN total_volume_mm3,
+    DROP COLUMN shipments;
+
+ALTER TABLE order_lines
+    DROP COLUMN total_weight_grams,
+    DROP COLUMN total_volume_mm3;
+
+ALTER TABLE pack_sizes
+    DROP COLUMN weight_grams,
+    DROP COLUMN length_mm,
+    DROP COLUMN width_mm,
+    DROP COLUMN height_mm;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Orders created before this migration get the time it ran
+ALTER TABLE orders ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Pages are read in the order of the sort field, with the ID breaking ties
+CREATE INDEX orders_created_at_id_idx ON orders (created_at, id);
+CREATE INDEX orders_items_count_id_idx ON orders (items_count, id);
+
+CREATE INDEX order_lines_product_id_idx ON order_lines (product_id);
+CREATE INDEX order_lines_packs_idx ON order_lines USING GIN (packs jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX order_lines_packs_idx;
+DROP INDEX order_lines_product_id_idx;
+DROP INDEX orders_items_count_id_idx;
+DROP INDEX orders_created_at_id_idx;
+
+ALTER TABLE orders DROP COLUMN created_at;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'quoted'
+        CHECK (status IN ('quoted', 'confirmed', 'packed', 'shipped', 'cancelled')),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE orders SET updated_at = created_at;
+
+CREATE TABLE order_status_history (
+    id UUID NOT NULL PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_by TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_status_history_order_id_changed_at_idx ON order_status_history (order_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_status_history;
+
+ALTER TABLE orders
+    DROP COLUMN status,
+    DROP COLUMN updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Like created_at and items_count, the other sort fields are read in pages
+-- with the ID breaking ties
+CREATE INDEX orders_shipped_items_id_idx ON orders (shipped_items, id);
+CREATE INDEX orders_total_packs_id_idx ON orders (total_packs, id);
+CREATE INDEX orders_total_cost_cents_id_idx ON orders (total_cost_cents, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX orders_total_cost_cents_id_idx;
+DROP INDEX orders_total_packs_id_idx;
+DROP INDEX orders_shipped_items_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+package models
+
+import "github.com/google/uuid"
+
+// CatalogChange tells the API replicas that the pack sizes of a product
+// changed. Origin is the replica that made the change, and a nil ProductID
+// means any product may have changed.
+type CatalogChange struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Origin    uuid.UUID `json:"origin"`
+}
@@ -1,9 +1,156 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// OrderPack keeps the price, weight and volume of a single pack as they were
+// when the order was packed.
+type OrderPack struct {
+	PackSizeID    uuid.UUID `json:"pack_size_id"`
+	PackSize      int       `json:"pack_size"`
+	Quantity      int       `json:"quantity"`
+	UnitCostCents int       `json:"unit_cost_cents"`
+	WeightGrams   int       `json:"weight_grams"`
+	VolumeMm3     int       `json:"volume_mm3"`
+}
+
+// PackingCandidate is a packing a line could be shipped with, scored by the
+// items it ships and the packs it takes.
+type PackingCandidate struct {
+	PackSetup      string      `json:"pack_setup"`
+	Packs          []OrderPack `json:"packs"`
+	ShippedItems   int         `json:"shipped_items"`
+	Overshoot      int         `json:"overshoot"`
+	TotalPacks     int         `json:"total_packs"`
+	TotalCostCents int         `json:"total_cost_cents"`
+	Reason         string      `json:"reason"`
+}
+
+// PackingExplanation is the packing chosen for a line next to the
+// alternatives it was weighed against. It's only returned on request and
+// isn't stored with the order.
+type PackingExplanation struct {
+	Chosen       PackingCandidate   `json:"chosen"`
+	Alternatives []PackingCandidate `json:"alternatives"`
+}
+
+// ShipmentPack is a number of packs of one line of the order that travel in
+// a shipment.
+type ShipmentPack struct {
+	LineNumber int       `json:"line_number"`
+	PackSizeID uuid.UUID `json:"pack_size_id"`
+	PackSize   int       `json:"pack_size"`
+	Quantity   int       `json:"quantity"`
+}
+
+// Shipment is a parcel group sent together, kept within the carrier limits
+// on the number of parcels and the total weight.
+type Shipment struct {
+	Number           int            `json:"number"`
+	Packs            []ShipmentPack `json:"packs"`
+	TotalPacks       int            `json:"total_packs"`
+	TotalWeightGrams int            `json:"total_weight_grams"`
+	TotalVolumeMm3   int            `json:"total_volume_mm3"`
+}
+
+// OrderLine is one product of an order, packed independently with that
+// product's pack sizes. The cost, weight and volume totals are those of the
+// line's packs.
+type OrderLine struct {
+	ID               uuid.UUID           `json:"id"`
+	OrderID          uuid.UUID           `json:"order_id"`
+	LineNumber       int                 `json:"line_number"`
+	ProductID        uuid.UUID           `json:"product_id"`
+	ItemsCount       int                 `json:"items_count"`
+	PackSetup        string              `json:"pack_setup"`
+	Packs            []OrderPack         `json:"packs"`
+	ShippedItems     int                 `json:"shipped_items"`
+	Overshoot        int                 `json:"overshoot"`
+	TotalPacks       int                 `json:"total_packs"`
+	Catalog          []PackSize          `json:"catalog"`
+	CatalogID        *uuid.UUID          `json:"catalog_id"`
+	TotalCostCents   int                 `json:"total_cost_cents"`
+	TotalWeightGrams int                 `json:"total_weight_grams"`
+	TotalVolumeMm3   int                 `json:"total_volume_mm3"`
+	Explanation      *PackingExplanation `json:"explanation,omitempty"`
+}
+
+// OrderStatus is where an order is in its lifecycle. Orders start quoted
+// and move forward through confirmed, packed and shipped, and can be
+// cancelled until they're shipped.
+type OrderStatus string
+
+const (
+	OrderStatusQuoted    OrderStatus = "quoted"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusPacked    OrderStatus = "packed"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// OrderStatusChange records who moved an order from one status to another
+// and when.
+type OrderStatusChange struct {
+	ID         uuid.UUID   `json:"id"`
+	OrderID    uuid.UUID   `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status"`
+	ChangedBy  string      `json:"changed_by"`
+	ChangedAt  time.Time   `json:"changed_at"`
+}
+
+// Order totals are summed over its lines, with the handling cost of every
+// shipment added to the total cost. The product, breakdown and catalog are
+// only set at the order level when the order has a single line.
 type Order struct {
-	ID         uuid.UUID `json:"id"`
-	ItemsCount int       `json:"items_count"`
-	PackSetup  string    `json:"pack_setup"`
+	ID                uuid.UUID           `json:"id"`
+	ProductID         *uuid.UUID          `json:"product_id"`
+	ItemsCount        int                 `json:"items_count"`
+	PackSetup         string              `json:"pack_setup"`
+	Packs             []OrderPack         `json:"packs"`
+	ShippedItems      int                 `json:"shipped_items"`
+	Overshoot         int                 `json:"overshoot"`
+	TotalPacks        int                 `json:"total_packs"`
+	Catalog           []PackSize          `json:"catalog"`
+	CatalogID         *uuid.UUID          `json:"catalog_id"`
+	Strategy          string              `json:"strategy"`
+	HandlingCostCents int                 `json:"handling_cost_cents"`
+	TotalCostCents    int                 `json:"total_cost_cents"`
+	TotalWeightGrams  int                 `json:"total_weight_grams"`
+	TotalVolumeMm3    int                 `json:"total_volume_mm3"`
+	Shipments         []Shipment          `json:"shipments"`
+	Status            OrderStatus         `json:"status"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	Lines             []OrderLine         `json:"lines"`
+	Explanation       *PackingExplanation `json:"explanation,omitempty"`
+	// Replayed marks an order returned again for a retried request
+	Replayed bool `json:"-" swaggerignore:"true"`
+}
+
+// OrderRevision keeps the breakdown an order had before it was amended.
+// Revisions are numbered from 1 in the order they were made.
+type OrderRevision struct {
+	ID        uuid.UUID `json:"id"`
+	OrderID   uuid.UUID `json:"order_id"`
+	Revision  int       `json:"revision"`
+	Previous  Order     `json:"previous"`
+	ChangedBy string    `json:"changed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IdempotencyKey ties the Idempotency-Key of an order creation request to
+// the order it created, so retries of the same request get the response of
+// the first one back rather than a new order until the key expires. Keys
+// recorded before responses were kept have no response.
+type IdempotencyKey struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	OrderID     uuid.UUID `json:"order_id"`
+	Response    *Order    `json:"response"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
@@ -1,8 +1,65 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// Inactive pack sizes are kept for history but left out of new catalog
+// versions, so orders don't use them until they're activated again. A nil
+// Stock means the pack size isn't tracked and is always available.
+// UnitCostCents is the price of a single pack, and the weight and outer
+// dimensions of a single pack are used to split orders into shipments.
 type PackSize struct {
-	ID   uuid.UUID `json:"id"`
-	Size int       `json:"size"`
+	ID            uuid.UUID `json:"id"`
+	ProductID     uuid.UUID `json:"product_id"`
+	Size          int       `json:"size"`
+	Active        bool      `json:"active"`
+	Stock         *int      `json:"stock"`
+	UnitCostCents int       `json:"unit_cost_cents"`
+	WeightGrams   int       `json:"weight_grams"`
+	LengthMm      int       `json:"length_mm"`
+	WidthMm       int       `json:"width_mm"`
+	HeightMm      int       `json:"height_mm"`
+}
+
+// VolumeMm3 is the volume of a single pack.
+func (ps PackSize) VolumeMm3() int {
+	return ps.LengthMm * ps.WidthMm * ps.HeightMm
+}
+
+// PackSizeCatalog is an immutable version of the pack sizes that becomes
+// effective at EffectiveFrom and stays effective until a newer one does.
+type PackSizeCatalog struct {
+	ID            uuid.UUID  `json:"id"`
+	ProductID     uuid.UUID  `json:"product_id"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	CreatedAt     time.Time  `json:"created_at"`
+	PackSizes     []PackSize `json:"pack_sizes"`
+}
+
+// ProductPackSizes is the state a change of a product's pack sizes is made
+// from: all of its pack sizes, the catalog version effective at At, nil
+// when none was published yet, and the versions scheduled after At, soonest
+// first.
+type ProductPackSizes struct {
+	At        time.Time
+	PackSizes []PackSize
+	Current   *PackSizeCatalog
+	Pending   []PackSizeCatalog
+}
+
+// PackSizeChanges are the pack sizes created, updated and deleted by a
+// change, the catalog versions it publishes, the scheduled versions it
+// rewrites and the notification sent to the other replicas, if any.
+// Updates leave the stock alone, since orders take it out and put it back
+// on their own.
+type PackSizeChanges struct {
+	Created      []PackSize
+	Updated      []PackSize
+	Deleted      []uuid.UUID
+	Catalogs     []PackSizeCatalog
+	Pending      []PackSizeCatalog
+	Notification *CatalogChange
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultProductID identifies the product created by the products
+// migration, which owns everything created before products existed.
+var DefaultProductID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type Product struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku"`
+	CreatedAt time.Time `json:"created_at"`
+}
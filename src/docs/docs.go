@@ -25,26 +25,79 @@ const docTemplate = `{
     "paths": {
         "/orders": {
             "get": {
-                "description": "Retrieve a list of all orders",
+                "description": "Retrieve a page of the orders matching the filters, with the cursor of the next page and the total count",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
                     "Orders"
                 ],
-                "summary": "Get all orders",
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Orders per page, 50 by default and at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, items_count, shipped_items, total_packs or total_cost_cents, prefixed with - for descending (default -created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders of at least this many items",
+                        "name": "min_items_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders of at most this many items",
+                        "name": "max_items_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, only orders created at or after it",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, only orders created before it",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders with a line of this product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders that use packs of this size",
+                        "name": "pack_size",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
+                            "$ref": "#/definitions/payload.OrdersPage"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
@@ -58,7 +111,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create an order with the specified number of items",
+                "description": "Create an order with one or more lines, each packed with the pack sizes of its product",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,6 +128,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/payload.CreateOrder"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the alternatives every line's packing was weighed against",
+                        "name": "explain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key and body get the response of the first request again, even if the order changed since",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -82,6 +147,12 @@ const docTemplate = `{
                         "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true when the response is replayed for a retry"
+                            }
                         }
                     },
                     "400": {
@@ -90,6 +161,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -99,30 +188,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{order_id}": {
-            "get": {
-                "description": "Retrieve the details of an order using its ID",
+        "/orders/bulk": {
+            "post": {
+                "description": "Create a batch of orders, sent as a JSON array or as NDJSON (one order per line, with the application/x-ndjson content type). Atomic batches create every order or none of them, partial batches create the orders they can and report the others",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/x-ndjson"
                 ],
                 "tags": [
                     "Orders"
                 ],
-                "summary": "Get an order by ID",
+                "summary": "Create orders in bulk",
                 "parameters": [
+                    {
+                        "description": "the orders to be created",
+                        "name": "orders",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/payload.CreateOrder"
+                            }
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "The ID of the order to retrieve",
-                        "name": "order_id",
-                        "in": "path",
-                        "required": true
+                        "description": "atomic (default) or partial",
+                        "name": "mode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/payload.BulkOrdersResult"
+                        }
+                    },
+                    "207": {
+                        "description": "Some orders of a partial batch failed",
+                        "schema": {
+                            "$ref": "#/definitions/payload.BulkOrdersResult"
                         }
                     },
                     "400": {
@@ -131,12 +238,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     },
+                    "422": {
+                        "description": "Some orders of an atomic batch failed, none was created",
+                        "schema": {
+                            "$ref": "#/definitions/payload.BulkOrdersResult"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -146,65 +259,109 @@ const docTemplate = `{
                 }
             }
         },
-        "/pack-sizes": {
+        "/orders/export": {
             "get": {
-                "description": "Retrieve a list of all available pack sizes",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Stream every order matching the filters as a CSV or XLSX spreadsheet, with a row per order or per pack of every order line",
                 "tags": [
-                    "PackSizes"
+                    "Orders"
+                ],
+                "summary": "Export orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default) or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "orders (default) or packs",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, items_count, shipped_items, total_packs or total_cost_cents, prefixed with - for descending (default created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders of at least this many items",
+                        "name": "min_items_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders of at most this many items",
+                        "name": "max_items_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, only orders created at or after it",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp, only orders created before it",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders with a line of this product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders that use packs of this size",
+                        "name": "pack_size",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all pack sizes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.PackSize"
-                            }
+                            "type": "file"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/orders/import": {
             "post": {
-                "description": "Add a new pack size to the system",
+                "description": "Create an order for every row of a CSV with an items_count column and optional product_id, strategy and order columns, sent as the body or as the file field of a form. Rows with the same order are the lines of a single order. Nothing is imported when any row has errors",
                 "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+                    "text/csv",
+                    "multipart/form-data"
                 ],
                 "tags": [
-                    "PackSizes"
+                    "Orders"
                 ],
-                "summary": "Create a new pack size",
+                "summary": "Import orders from a CSV",
                 "parameters": [
                     {
-                        "description": "The pack size to create",
-                        "name": "packSize",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/payload.CreatePackSize"
-                        }
+                        "type": "file",
+                        "description": "The CSV, when sent as a form",
+                        "name": "file",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.PackSize"
+                            "$ref": "#/definitions/payload.OrdersImport"
                         }
                     },
                     "400": {
@@ -213,6 +370,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Rows with errors, nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/payload.OrdersImport"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -222,34 +391,76 @@ const docTemplate = `{
                 }
             }
         },
-        "/pack-sizes/{pack_size_id}": {
-            "put": {
-                "description": "Update the size of an existing pack size",
+        "/orders/{order_id}": {
+            "get": {
+                "description": "Retrieve the details of an order using its ID",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get an order by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order to retrieve",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Change the items count of an order, packing it again with the current catalog and keeping the previous breakdown as a revision. Shipped and cancelled orders can't be amended",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "PackSizes"
+                    "Orders"
                 ],
-                "summary": "Update an existing pack size",
+                "summary": "Amend an order",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The ID of the pack size to update",
-                        "name": "id",
+                        "description": "The ID of the order",
+                        "name": "order_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "The updated pack size data",
-                        "name": "packSize",
+                        "description": "The new items count or lines and who changed them",
+                        "name": "order",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payload.UpdatePackSize"
+                            "$ref": "#/definitions/payload.AmendOrder"
                         }
                     }
                 ],
@@ -257,7 +468,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.PackSize"
+                            "$ref": "#/definitions/models.Order"
                         }
                     },
                     "400": {
@@ -266,6 +477,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/payload.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -273,43 +502,1935 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.Order": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "items_count": {
-                    "type": "integer"
-                },
-                "pack_setup": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.PackSize": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "size": {
-                    "type": "integer"
-                }
-            }
-        },
-        "payload.CreateOrder": {
+            },
+            "delete": {
+                "description": "Mark an order cancelled and put its packs back in stock. Shipped orders can't be cancelled",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Cancel an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Who cancelled the order",
+                        "name": "changed_by",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/recalculation": {
+            "get": {
+                "description": "Compare an existing order against the breakdown the current pack sizes would produce, without changing it",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Recalculate an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order to recalculate",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payload.OrderRecalculation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/revisions": {
+            "get": {
+                "description": "Retrieve the breakdowns an order had before each of its amendments, from the oldest",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get the revisions of an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderRevision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/status": {
+            "patch": {
+                "description": "Move an order through its lifecycle: quoted, confirmed, packed and shipped, or cancelled until it's shipped. The change is recorded in the order's status history",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Update the status of an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new status and who changed it",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.UpdateOrderStatus"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/status-history": {
+            "get": {
+                "description": "Retrieve every status change of an order, who made it and when, from the oldest",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Get the status history of an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the order",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrderStatusChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes": {
+            "get": {
+                "description": "Retrieve a list of the pack sizes of a product filtered by status, or the catalog that was effective at the given time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Get all pack sizes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status: active, inactive or all (default)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp to get the catalog effective at",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PackSize"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the pack sizes of a product with the given sizes in a single transaction and publish them as a catalog effective immediately. Sizes the product already has keep their stock, cost and dimensions, and the others are deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Replace every pack size",
+                "parameters": [
+                    {
+                        "description": "The sizes of the new catalog",
+                        "name": "packSizes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.ReplacePackSizes"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSizeCatalog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new pack size to a product, or to the default product on /pack-sizes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Create a new pack size",
+                "parameters": [
+                    {
+                        "description": "The pack size to create",
+                        "name": "packSize",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.CreatePackSize"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSize"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes/catalogs": {
+            "get": {
+                "description": "Retrieve every catalog version of a product, newest effective date first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Get all pack size catalogs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PackSizeCatalog"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a catalog version of a product with the given active sizes that becomes effective at the given time. Pack sizes changed before then are changed in the scheduled version too, so it never brings back a deleted, deactivated or repriced size",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Schedule a pack size catalog",
+                "parameters": [
+                    {
+                        "description": "The catalog to schedule",
+                        "name": "catalog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.ScheduleCatalog"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSizeCatalog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes/import": {
+            "post": {
+                "description": "Create a pack size for every row of a CSV with a size column and optional stock, unit_cost_cents, weight_grams, length_mm, width_mm and height_mm columns, sent as the body or as the file field of a form. Nothing is imported when any row has errors",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Import pack sizes from a CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "The CSV, when sent as a form",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/payload.PackSizesImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Rows with errors, nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/payload.PackSizesImport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes/{pack_size_id}": {
+            "put": {
+                "description": "Update the size or unit cost of an existing pack size and/or activate or deactivate it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Update an existing pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the pack size to update",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The updated pack size data",
+                        "name": "packSize",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.UpdatePackSize"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSize"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a pack size for good, catalog versions and orders that used it keep their own snapshot of it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Delete a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the pack size to delete",
+                        "name": "pack_size_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes/{pack_size_id}/dimensions": {
+            "put": {
+                "description": "Set the weight in grams and the outer dimensions in millimetres of a single pack, used to split orders into shipments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Set the weight and dimensions of a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the pack size",
+                        "name": "pack_size_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The weight and dimensions of a pack",
+                        "name": "dimensions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.UpdatePackSizeDimensions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSize"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pack-sizes/{pack_size_id}/stock": {
+            "put": {
+                "description": "Set how many packs of a size are in stock, or stop tracking it with a null stock so the size is always available",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Set the stock of a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the pack size",
+                        "name": "pack_size_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The packs in stock",
+                        "name": "stock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.UpdatePackSizeStock"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSize"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Retrieve a list of all products",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get all products",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new product that can have its own pack sizes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Create a new product",
+                "parameters": [
+                    {
+                        "description": "The product to create",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.CreateProduct"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}": {
+            "get": {
+                "description": "Retrieve the details of a product using its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Get a product by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product to retrieve",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the name and SKU of an existing product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Update an existing product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product to update",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The updated product data",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.UpdateProduct"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a product together with its pack sizes, as long as it was never ordered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Products"
+                ],
+                "summary": "Delete a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product to delete",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}/pack-sizes": {
+            "get": {
+                "description": "Retrieve a list of the pack sizes of a product filtered by status, or the catalog that was effective at the given time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Get all pack sizes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status: active, inactive or all (default)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 timestamp to get the catalog effective at",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PackSize"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the pack sizes of a product with the given sizes in a single transaction and publish them as a catalog effective immediately. Sizes the product already has keep their stock, cost and dimensions, and the others are deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Replace every pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The sizes of the new catalog",
+                        "name": "packSizes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.ReplacePackSizes"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSizeCatalog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new pack size to a product, or to the default product on /pack-sizes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Create a new pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The pack size to create",
+                        "name": "packSize",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.CreatePackSize"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSize"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}/pack-sizes/catalogs": {
+            "get": {
+                "description": "Retrieve every catalog version of a product, newest effective date first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Get all pack size catalogs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PackSizeCatalog"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a catalog version of a product with the given active sizes that becomes effective at the given time. Pack sizes changed before then are changed in the scheduled version too, so it never brings back a deleted, deactivated or repriced size",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Schedule a pack size catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The catalog to schedule",
+                        "name": "catalog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.ScheduleCatalog"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PackSizeCatalog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{product_id}/pack-sizes/import": {
+            "post": {
+                "description": "Create a pack size for every row of a CSV with a size column and optional stock, unit_cost_cents, weight_grams, length_mm, width_mm and height_mm columns, sent as the body or as the file field of a form. Nothing is imported when any row has errors",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PackSizes"
+                ],
+                "summary": "Import pack sizes from a CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product",
+                        "name": "product_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "The CSV, when sent as a form",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/payload.PackSizesImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Rows with errors, nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/payload.PackSizesImport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes": {
+            "post": {
+                "description": "Calculate the pack breakdown of every line of an order without creating it",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Orders"
+                ],
+                "summary": "Quote an order",
+                "parameters": [
+                    {
+                        "description": "the order to be quoted",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.CreateOrder"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the alternatives every line's packing was weighed against",
+                        "name": "explain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/payload.Quote"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/payload.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "catalog": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                },
+                "catalog_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "$ref": "#/definitions/models.PackingExplanation"
+                },
+                "handling_cost_cents": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderLine"
+                    }
+                },
+                "overshoot": {
+                    "type": "integer"
+                },
+                "pack_setup": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderPack"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "shipments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Shipment"
+                    }
+                },
+                "shipped_items": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "strategy": {
+                    "type": "string"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_volume_mm3": {
+                    "type": "integer"
+                },
+                "total_weight_grams": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderLine": {
+            "type": "object",
+            "properties": {
+                "catalog": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                },
+                "catalog_id": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "$ref": "#/definitions/models.PackingExplanation"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "line_number": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "overshoot": {
+                    "type": "integer"
+                },
+                "pack_setup": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderPack"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "shipped_items": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_volume_mm3": {
+                    "type": "integer"
+                },
+                "total_weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderPack": {
+            "type": "object",
+            "properties": {
+                "pack_size": {
+                    "type": "integer"
+                },
+                "pack_size_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_cost_cents": {
+                    "type": "integer"
+                },
+                "volume_mm3": {
+                    "type": "integer"
+                },
+                "weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderRevision": {
+            "type": "object",
+            "properties": {
+                "changed_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "previous": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "revision": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "quoted",
+                "confirmed",
+                "packed",
+                "shipped",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "OrderStatusQuoted",
+                "OrderStatusConfirmed",
+                "OrderStatusPacked",
+                "OrderStatusShipped",
+                "OrderStatusCancelled"
+            ]
+        },
+        "models.OrderStatusChange": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "to_status": {
+                    "$ref": "#/definitions/models.OrderStatus"
+                }
+            }
+        },
+        "models.PackSize": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "height_mm": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "length_mm": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "unit_cost_cents": {
+                    "type": "integer"
+                },
+                "weight_grams": {
+                    "type": "integer"
+                },
+                "width_mm": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PackSizeCatalog": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PackingCandidate": {
+            "type": "object",
+            "properties": {
+                "overshoot": {
+                    "type": "integer"
+                },
+                "pack_setup": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderPack"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "shipped_items": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PackingExplanation": {
+            "type": "object",
+            "properties": {
+                "alternatives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackingCandidate"
+                    }
+                },
+                "chosen": {
+                    "$ref": "#/definitions/models.PackingCandidate"
+                }
+            }
+        },
+        "models.Product": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Shipment": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "integer"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ShipmentPack"
+                    }
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_volume_mm3": {
+                    "type": "integer"
+                },
+                "total_weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ShipmentPack": {
+            "type": "object",
+            "properties": {
+                "line_number": {
+                    "type": "integer"
+                },
+                "pack_size": {
+                    "type": "integer"
+                },
+                "pack_size_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payload.AmendOrder": {
             "type": "object",
             "required": [
-                "items_count"
+                "changed_by"
             ],
+            "properties": {
+                "changed_by": {
+                    "type": "string"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.OrderLine"
+                    }
+                }
+            }
+        },
+        "payload.BulkOrderResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "payload.BulkOrdersResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.BulkOrderResult"
+                    }
+                }
+            }
+        },
+        "payload.CreateOrder": {
+            "type": "object",
             "properties": {
                 "items_count": {
                     "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.OrderLine"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "fewest-items",
+                        "fewest-packs",
+                        "greedy",
+                        "cheapest"
+                    ]
                 }
             }
         },
@@ -319,8 +2440,47 @@ const docTemplate = `{
                 "size"
             ],
             "properties": {
+                "height_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "length_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "size": {
                     "type": "integer"
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "weight_grams": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "width_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "payload.CreateProduct": {
+            "type": "object",
+            "required": [
+                "name",
+                "sku"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
                 }
             }
         },
@@ -332,18 +2492,347 @@ const docTemplate = `{
                 }
             }
         },
-        "payload.UpdatePackSize": {
+        "payload.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payload.OrderLine": {
             "type": "object",
             "required": [
-                "id",
-                "size"
+                "items_count"
+            ],
+            "properties": {
+                "items_count": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "payload.OrderRecalculation": {
+            "type": "object",
+            "properties": {
+                "catalog_changed": {
+                    "type": "boolean"
+                },
+                "current": {
+                    "$ref": "#/definitions/payload.Quote"
+                },
+                "order": {
+                    "$ref": "#/definitions/models.Order"
+                },
+                "packs_changed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "payload.OrdersImport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.ImportRowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                }
+            }
+        },
+        "payload.OrdersPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payload.PackSizesImport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.ImportRowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                }
+            }
+        },
+        "payload.Quote": {
+            "type": "object",
+            "properties": {
+                "catalog": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                },
+                "catalog_id": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "$ref": "#/definitions/models.PackingExplanation"
+                },
+                "handling_cost_cents": {
+                    "type": "integer"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payload.QuoteLine"
+                    }
+                },
+                "overshoot": {
+                    "type": "integer"
+                },
+                "pack_setup": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderPack"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "shipments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Shipment"
+                    }
+                },
+                "shipped_items": {
+                    "type": "integer"
+                },
+                "strategy": {
+                    "type": "string"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_volume_mm3": {
+                    "type": "integer"
+                },
+                "total_weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payload.QuoteLine": {
+            "type": "object",
+            "properties": {
+                "catalog": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackSize"
+                    }
+                },
+                "catalog_id": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "$ref": "#/definitions/models.PackingExplanation"
+                },
+                "items_count": {
+                    "type": "integer"
+                },
+                "overshoot": {
+                    "type": "integer"
+                },
+                "pack_setup": {
+                    "type": "string"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderPack"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "shipped_items": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_volume_mm3": {
+                    "type": "integer"
+                },
+                "total_weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payload.ReplacePackSizes": {
+            "type": "object",
+            "required": [
+                "sizes"
+            ],
+            "properties": {
+                "sizes": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "payload.ScheduleCatalog": {
+            "type": "object",
+            "required": [
+                "effective_from",
+                "sizes"
+            ],
+            "properties": {
+                "effective_from": {
+                    "type": "string"
+                },
+                "sizes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "payload.UpdateOrderStatus": {
+            "type": "object",
+            "required": [
+                "changed_by",
+                "status"
             ],
             "properties": {
+                "changed_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "enum": [
+                        "quoted",
+                        "confirmed",
+                        "packed",
+                        "shipped",
+                        "cancelled"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrderStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "payload.UpdatePackSize": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "string"
                 },
                 "size": {
                     "type": "integer"
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "payload.UpdatePackSizeDimensions": {
+            "type": "object",
+            "properties": {
+                "height_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "length_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "weight_grams": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "width_mm": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "payload.UpdatePackSizeStock": {
+            "type": "object",
+            "properties": {
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "payload.UpdateProduct": {
+            "type": "object",
+            "required": [
+                "name",
+                "sku"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
                 }
             }
         }
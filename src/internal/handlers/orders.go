@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/log"
@@ -12,9 +20,19 @@ import (
 )
 
 type OrderService interface {
-	CreateOrder(itemsCount int) (models.Order, error)
+	CreateOrder(input payload.CreateOrder) (models.Order, error)
+	CreateOrders(input payload.CreateOrders) (payload.BulkOrdersResult, error)
+	ImportOrders(r io.Reader) (payload.OrdersImport, error)
+	QuoteOrder(input payload.CreateOrder) (payload.Quote, error)
 	GetOrder(orderID uuid.UUID) (models.Order, error)
-	GetAllOrders() ([]models.Order, error)
+	ListOrders(query payload.ListOrders) (payload.OrdersPage, error)
+	ExportOrders(input payload.ExportOrders) (func(w io.Writer) error, error)
+	RecalculateOrder(orderID uuid.UUID) (payload.OrderRecalculation, error)
+	UpdateOrderStatus(orderID uuid.UUID, input payload.UpdateOrderStatus) (models.Order, error)
+	GetOrderStatusHistory(orderID uuid.UUID) ([]models.OrderStatusChange, error)
+	AmendOrder(orderID uuid.UUID, input payload.AmendOrder) (models.Order, error)
+	CancelOrder(orderID uuid.UUID, changedBy string) (models.Order, error)
+	GetOrderRevisions(orderID uuid.UUID) ([]models.OrderRevision, error)
 }
 
 type OrdersHandler struct {
@@ -30,13 +48,19 @@ func NewOrdersHandler(orderService OrderService) *OrdersHandler {
 // CreateOrder godoc
 //
 //	@Summary		Create an order
-//	@Description	Create an order with the specified number of items
+//	@Description	Create an order with one or more lines, each packed with the pack sizes of its product
 //	@Tags			Orders
 //	@Accept			json
 //	@Produces		json
 //	@Param			order	body		payload.CreateOrder	true	"the order to be created"
+//	@Param			explain	query		bool				false	"Return the alternatives every line's packing was weighed against"
+//	@Param			Idempotency-Key	header	string			false	"Retries with the same key and body get the response of the first request again, even if the order changed since"
 //	@Success		201			{object}	models.Order
+//	@Header			201			{string}	Idempotent-Replayed	"true when the response is replayed for a retry"
 //	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		422			{object}	payload.ErrorResponse
 //	@Failure		500			{object}	payload.ErrorResponse
 //	@Router			/orders [post]
 func (h *OrdersHandler) CreateOrder(ctx fiber.Ctx) error {
@@ -44,15 +68,235 @@ func (h *OrdersHandler) CreateOrder(ctx fiber.Ctx) error {
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "badly formed request"})
 	}
+	input.Explain = ctx.Query("explain") == "true"
+	input.IdempotencyKey = ctx.Get("Idempotency-Key")
 
-	order, err := h.orderService.CreateOrder(input.ItemsCount)
+	order, err := h.orderService.CreateOrder(input)
 	if err != nil {
+		if status, packingErr := packingError(err); packingErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: packingErr.Error()})
+		}
+		if errors.Is(err, payload.ErrInvalidIdempotencyKey) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrIdempotencyKeyReused) {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Errorf("Failed to create order: %v", err)
 		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to create order"})
 	}
 
+	if order.Replayed {
+		ctx.Set("Idempotent-Replayed", "true")
+	}
+
 	return ctx.Status(fiber.StatusCreated).JSON(order)
 }
 
+// CreateOrders godoc
+//
+//	@Summary		Create orders in bulk
+//	@Description	Create a batch of orders, sent as a JSON array or as NDJSON (one order per line, with the application/x-ndjson content type). Atomic batches create every order or none of them, partial batches create the orders they can and report the others
+//	@Tags			Orders
+//	@Accept			json
+//	@Accept			application/x-ndjson
+//	@Produces		json
+//	@Param			orders	body		[]payload.CreateOrder	true	"the orders to be created"
+//	@Param			mode	query		string					false	"atomic (default) or partial"
+//	@Success		201		{object}	payload.BulkOrdersResult
+//	@Success		207		{object}	payload.BulkOrdersResult	"Some orders of a partial batch failed"
+//	@Failure		400		{object}	payload.ErrorResponse
+//	@Failure		409		{object}	payload.ErrorResponse
+//	@Failure		422		{object}	payload.BulkOrdersResult	"Some orders of an atomic batch failed, none was created"
+//	@Failure		500		{object}	payload.ErrorResponse
+//	@Router			/orders/bulk [post]
+func (h *OrdersHandler) CreateOrders(ctx fiber.Ctx) error {
+	orders, err := bulkOrdersBody(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+	}
+
+	result, err := h.orderService.CreateOrders(payload.CreateOrders{Orders: orders, Mode: ctx.Query("mode")})
+	if err != nil {
+		if errors.Is(err, payload.ErrInvalidBulkMode) || errors.Is(err, payload.ErrEmptyBulk) || errors.Is(err, payload.ErrTooManyBulkOrders) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrInsufficientStock) {
+			return ctx.Status(fiber.StatusConflict).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Errorf("Failed to create orders: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to create orders"})
+	}
+
+	for i := range result.Results {
+		if result.Results[i].Err != nil {
+			result.Results[i].Error = bulkOrderError(result.Results[i].Err)
+		}
+	}
+
+	switch {
+	case result.Failed == 0:
+		return ctx.Status(fiber.StatusCreated).JSON(result)
+	case result.Mode == payload.BulkModePartial:
+		return ctx.Status(fiber.StatusMultiStatus).JSON(result)
+	default:
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(result)
+	}
+}
+
+// ImportOrders godoc
+//
+//	@Summary		Import orders from a CSV
+//	@Description	Create an order for every row of a CSV with an items_count column and optional product_id, strategy and order columns, sent as the body or as the file field of a form. Rows with the same order are the lines of a single order. Nothing is imported when any row has errors
+//	@Tags			Orders
+//	@Accept			text/csv
+//	@Accept			multipart/form-data
+//	@Produces		json
+//	@Param			file	formData	file	false	"The CSV, when sent as a form"
+//	@Success		201		{object}	payload.OrdersImport
+//	@Failure		400		{object}	payload.ErrorResponse
+//	@Failure		409		{object}	payload.ErrorResponse
+//	@Failure		422		{object}	payload.OrdersImport	"Rows with errors, nothing was imported"
+//	@Failure		500		{object}	payload.ErrorResponse
+//	@Router			/orders/import [post]
+func (h *OrdersHandler) ImportOrders(ctx fiber.Ctx) error {
+	file, err := utils.UploadedFile(ctx, "file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "missing CSV file"})
+	}
+	defer file.Close()
+
+	result, err := h.orderService.ImportOrders(file)
+	if err != nil {
+		if errors.Is(err, payload.ErrEmptyImport) || errors.Is(err, payload.ErrTooManyImportRows) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrInsufficientStock) {
+			return ctx.Status(fiber.StatusConflict).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Errorf("Failed to import orders: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to import orders"})
+	}
+
+	if len(result.Errors) == 0 {
+		return ctx.Status(fiber.StatusCreated).JSON(result)
+	}
+
+	for i := range result.Errors {
+		if result.Errors[i].Err != nil {
+			result.Errors[i].Message = bulkOrderError(result.Errors[i].Err)
+		}
+	}
+
+	return ctx.Status(fiber.StatusUnprocessableEntity).JSON(result)
+}
+
+// bulkOrdersBody decodes the orders of a bulk request, either a JSON array
+// or NDJSON.
+func bulkOrdersBody(ctx fiber.Ctx) ([]payload.CreateOrder, error) {
+	contentType := strings.TrimSpace(strings.Split(ctx.Get(fiber.HeaderContentType), ";")[0])
+	if contentType != "application/x-ndjson" && contentType != "application/jsonl" {
+		orders, err := utils.UnmarshalRequest[[]payload.CreateOrder](ctx)
+		if err != nil {
+			return nil, errors.New("badly formed request, expected an array of orders")
+		}
+
+		return orders, nil
+	}
+
+	orders := []payload.CreateOrder{}
+	decoder := json.NewDecoder(bytes.NewReader(ctx.Body()))
+	for {
+		var order payload.CreateOrder
+		if err := decoder.Decode(&order); err != nil {
+			if errors.Is(err, io.EOF) {
+				return orders, nil
+			}
+
+			return nil, fmt.Errorf("badly formed order on line %d", len(orders)+1)
+		}
+
+		orders = append(orders, order)
+	}
+}
+
+// packingError returns the status and the error reported to the client for
+// the errors quoting an order can fail with, or a nil error for any other
+// error.
+func packingError(err error) (int, error) {
+	statuses := []struct {
+		err    error
+		status int
+	}{
+		{payload.ErrProductNotFound, fiber.StatusNotFound},
+		{payload.ErrAmbiguousOrderLines, fiber.StatusBadRequest},
+		{payload.ErrInvalidItemsCount, fiber.StatusBadRequest},
+		{payload.ErrUnknownStrategy, fiber.StatusBadRequest},
+		{payload.ErrInsufficientStock, fiber.StatusConflict},
+		{payload.ErrPackTooHeavy, fiber.StatusUnprocessableEntity},
+		{payload.ErrTooManyItems, fiber.StatusUnprocessableEntity},
+		{payload.ErrTooManyBoundedItems, fiber.StatusUnprocessableEntity},
+		{payload.ErrNoPackSizes, fiber.StatusUnprocessableEntity},
+	}
+	for _, candidate := range statuses {
+		if errors.Is(err, candidate.err) {
+			return candidate.status, candidate.err
+		}
+	}
+
+	return fiber.StatusInternalServerError, nil
+}
+
+// bulkOrderError is the message reported for an order of a bulk request
+// that failed.
+func bulkOrderError(err error) string {
+	if _, packingErr := packingError(err); packingErr != nil {
+		return packingErr.Error()
+	}
+
+	log.Errorf("Failed to create order in bulk: %v", err)
+	return "failed to create order"
+}
+
+// QuoteOrder godoc
+//
+//	@Summary		Quote an order
+//	@Description	Calculate the pack breakdown of every line of an order without creating it
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order	body		payload.CreateOrder	true	"the order to be quoted"
+//	@Param			explain	query		bool				false	"Return the alternatives every line's packing was weighed against"
+//	@Success		200			{object}	payload.Quote
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		422			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/quotes [post]
+func (h *OrdersHandler) QuoteOrder(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.CreateOrder](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "badly formed request"})
+	}
+	input.Explain = ctx.Query("explain") == "true"
+
+	quote, err := h.orderService.QuoteOrder(input)
+	if err != nil {
+		if status, packingErr := packingError(err); packingErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: packingErr.Error()})
+		}
+
+		log.Errorf("Failed to quote order: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to quote order"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(quote)
+}
+
 // GetOrder godoc
 //
 //	@Summary		Get an order by ID
@@ -87,26 +331,400 @@ func (h *OrdersHandler) GetOrder(ctx fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(order)
 }
 
-// GetAllOrders godoc
+// RecalculateOrder godoc
 //
-//	@Summary		Get all orders
-//	@Description	Retrieve a list of all orders
+//	@Summary		Recalculate an order
+//	@Description	Compare an existing order against the breakdown the current pack sizes would produce, without changing it
 //	@Tags			Orders
 //	@Accept			json
 //	@Produces		json
-//	@Success		200	{array}		models.Order
-//	@Failure		404	{object}	payload.ErrorResponse
-//	@Failure		500	{object}	payload.ErrorResponse
-//	@Router			/orders [get]
-func (h *OrdersHandler) GetAllOrders(ctx fiber.Ctx) error {
-	orders, err := h.orderService.GetAllOrders()
+//	@Param			order_id	path		string	true	"The ID of the order to recalculate"
+//	@Success		200			{object}	payload.OrderRecalculation
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		422			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id}/recalculation [get]
+func (h *OrdersHandler) RecalculateOrder(ctx fiber.Ctx) error {
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	recalculation, err := h.orderService.RecalculateOrder(orderID)
+	if err != nil {
+		if errors.Is(err, payload.ErrOrderNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+		if status, packingErr := packingError(err); packingErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: packingErr.Error()})
+		}
+
+		log.Errorf("Failed to recalculate order: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to recalculate order"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(recalculation)
+}
+
+// AmendOrder godoc
+//
+//	@Summary		Amend an order
+//	@Description	Change the items count of an order, packing it again with the current catalog and keeping the previous breakdown as a revision. Shipped and cancelled orders can't be amended
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order_id	path		string				true	"The ID of the order"
+//	@Param			order		body		payload.AmendOrder	true	"The new items count or lines and who changed them"
+//	@Success		200			{object}	models.Order
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		422			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id} [put]
+func (h *OrdersHandler) AmendOrder(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.AmendOrder](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "badly formed request"})
+	}
+
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	order, err := h.orderService.AmendOrder(orderID, input)
+	if err != nil {
+		if status, packingErr := packingError(err); packingErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: packingErr.Error()})
+		}
+		if errors.Is(err, payload.ErrMissingChangedBy) || errors.Is(err, payload.ErrAmendLines) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrOrderNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+		if errors.Is(err, payload.ErrOrderNotAmendable) || errors.Is(err, payload.ErrOrderChanged) {
+			return ctx.Status(fiber.StatusConflict).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Error("failed to amend order:", err)
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to amend order"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(order)
+}
+
+// CancelOrder godoc
+//
+//	@Summary		Cancel an order
+//	@Description	Mark an order cancelled and put its packs back in stock. Shipped orders can't be cancelled
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order_id	path		string	true	"The ID of the order"
+//	@Param			changed_by	query		string	true	"Who cancelled the order"
+//	@Success		200			{object}	models.Order
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id} [delete]
+func (h *OrdersHandler) CancelOrder(ctx fiber.Ctx) error {
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	order, err := h.orderService.CancelOrder(orderID, ctx.Query("changed_by"))
+	if err != nil {
+		if errors.Is(err, payload.ErrMissingChangedBy) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrOrderNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+		if errors.Is(err, payload.ErrInvalidStatusTransition) {
+			return ctx.Status(fiber.StatusConflict).JSON(payload.ErrorResponse{Message: "the order can't be cancelled once it's shipped or cancelled"})
+		}
+
+		log.Error("failed to cancel order:", err)
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to cancel order"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(order)
+}
+
+// GetOrderRevisions godoc
+//
+//	@Summary		Get the revisions of an order
+//	@Description	Retrieve the breakdowns an order had before each of its amendments, from the oldest
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order_id	path		string	true	"The ID of the order"
+//	@Success		200			{array}		models.OrderRevision
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id}/revisions [get]
+func (h *OrdersHandler) GetOrderRevisions(ctx fiber.Ctx) error {
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	revisions, err := h.orderService.GetOrderRevisions(orderID)
+	if err != nil {
+		if errors.Is(err, payload.ErrOrderNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to retrieve order revisions"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(revisions)
+}
+
+// UpdateOrderStatus godoc
+//
+//	@Summary		Update the status of an order
+//	@Description	Move an order through its lifecycle: quoted, confirmed, packed and shipped, or cancelled until it's shipped. The change is recorded in the order's status history
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order_id	path		string						true	"The ID of the order"
+//	@Param			status		body		payload.UpdateOrderStatus	true	"The new status and who changed it"
+//	@Success		200			{object}	models.Order
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id}/status [patch]
+func (h *OrdersHandler) UpdateOrderStatus(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.UpdateOrderStatus](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "badly formed request"})
+	}
+
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	order, err := h.orderService.UpdateOrderStatus(orderID, input)
+	if err != nil {
+		if errors.Is(err, payload.ErrInvalidOrderStatus) || errors.Is(err, payload.ErrMissingChangedBy) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrOrderNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+		if errors.Is(err, payload.ErrInvalidStatusTransition) {
+			return ctx.Status(fiber.StatusConflict).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Error("failed to update order status:", err)
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to update order status"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(order)
+}
+
+// GetOrderStatusHistory godoc
+//
+//	@Summary		Get the status history of an order
+//	@Description	Retrieve every status change of an order, who made it and when, from the oldest
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			order_id	path		string	true	"The ID of the order"
+//	@Success		200			{array}		models.OrderStatusChange
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/orders/{order_id}/status-history [get]
+func (h *OrdersHandler) GetOrderStatusHistory(ctx fiber.Ctx) error {
+	orderID, err := uuid.Parse(ctx.Params("order_id"))
+	if err != nil {
+		log.Error("invalid order ID:", err)
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid order ID"})
+	}
+
+	history, err := h.orderService.GetOrderStatusHistory(orderID)
 	if err != nil {
 		if errors.Is(err, payload.ErrOrderNotFound) {
-			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "no orders found"})
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "order not found"})
+		}
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to retrieve order status history"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(history)
+}
+
+// ListOrders godoc
+//
+//	@Summary		List orders
+//	@Description	Retrieve a page of the orders matching the filters, with the cursor of the next page and the total count
+//	@Tags			Orders
+//	@Accept			json
+//	@Produces		json
+//	@Param			cursor			query		string	false	"The next_cursor of the previous page"
+//	@Param			limit			query		int		false	"Orders per page, 50 by default and at most 500"
+//	@Param			sort			query		string	false	"created_at, items_count, shipped_items, total_packs or total_cost_cents, prefixed with - for descending (default -created_at)"
+//	@Param			min_items_count	query		int		false	"Only orders of at least this many items"
+//	@Param			max_items_count	query		int		false	"Only orders of at most this many items"
+//	@Param			created_from	query		string	false	"RFC 3339 timestamp, only orders created at or after it"
+//	@Param			created_to		query		string	false	"RFC 3339 timestamp, only orders created before it"
+//	@Param			product_id		query		string	false	"Only orders with a line of this product"
+//	@Param			pack_size		query		int		false	"Only orders that use packs of this size"
+//	@Success		200				{object}	payload.OrdersPage
+//	@Failure		400				{object}	payload.ErrorResponse
+//	@Failure		500				{object}	payload.ErrorResponse
+//	@Router			/orders [get]
+func (h *OrdersHandler) ListOrders(ctx fiber.Ctx) error {
+	query, err := listOrdersQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+	}
+
+	page, err := h.orderService.ListOrders(query)
+	if err != nil {
+		if errors.Is(err, payload.ErrInvalidOrderSort) || errors.Is(err, payload.ErrInvalidCursor) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
 		}
 
 		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to retrieve orders"})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(orders)
+	return ctx.Status(fiber.StatusOK).JSON(page)
+}
+
+// ExportOrders godoc
+//
+//	@Summary		Export orders
+//	@Description	Stream every order matching the filters as a CSV or XLSX spreadsheet, with a row per order or per pack of every order line
+//	@Tags			Orders
+//	@Produces		text/csv
+//	@Produces		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format			query		string	false	"csv (default) or xlsx"
+//	@Param			rows			query		string	false	"orders (default) or packs"
+//	@Param			sort			query		string	false	"created_at, items_count, shipped_items, total_packs or total_cost_cents, prefixed with - for descending (default created_at)"
+//	@Param			min_items_count	query		int		false	"Only orders of at least this many items"
+//	@Param			max_items_count	query		int		false	"Only orders of at most this many items"
+//	@Param			created_from	query		string	false	"RFC 3339 timestamp, only orders created at or after it"
+//	@Param			created_to		query		string	false	"RFC 3339 timestamp, only orders created before it"
+//	@Param			product_id		query		string	false	"Only orders with a line of this product"
+//	@Param			pack_size		query		int		false	"Only orders that use packs of this size"
+//	@Success		200				{file}		file
+//	@Failure		400				{object}	payload.ErrorResponse
+//	@Router			/orders/export [get]
+func (h *OrdersHandler) ExportOrders(ctx fiber.Ctx) error {
+	query, err := listOrdersQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+	}
+
+	format := ctx.Query("format", payload.ExportFormatCSV)
+	write, err := h.orderService.ExportOrders(payload.ExportOrders{Query: query, Format: format, Rows: ctx.Query("rows")})
+	if err != nil {
+		if errors.Is(err, payload.ErrInvalidExportFormat) || errors.Is(err, payload.ErrInvalidExportRows) || errors.Is(err, payload.ErrInvalidOrderSort) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to export orders"})
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == payload.ExportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="orders.%s"`, format))
+
+	// The status is sent before the first row, so a failure halfway through
+	// can only cut the export short
+	return ctx.SendStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			log.Errorf("Failed to export orders: %v", err)
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			log.Errorf("Failed to send orders export: %v", err)
+		}
+	})
+}
+
+// listOrdersQuery reads the pagination, sorting and filters of GET /orders
+// from the query string.
+func listOrdersQuery(ctx fiber.Ctx) (payload.ListOrders, error) {
+	query := payload.ListOrders{
+		Cursor: ctx.Query("cursor"),
+		Sort:   ctx.Query("sort"),
+	}
+
+	ints := map[string]**int{
+		"min_items_count": &query.MinItemsCount,
+		"max_items_count": &query.MaxItemsCount,
+		"pack_size":       &query.PackSize,
+	}
+	for name, dest := range ints {
+		if raw := ctx.Query(name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return payload.ListOrders{}, fmt.Errorf("invalid %s, expected an integer", name)
+			}
+			*dest = &value
+		}
+	}
+
+	times := map[string]**time.Time{
+		"created_from": &query.CreatedFrom,
+		"created_to":   &query.CreatedTo,
+	}
+	for name, dest := range times {
+		if raw := ctx.Query(name); raw != "" {
+			value, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return payload.ListOrders{}, fmt.Errorf("invalid %s timestamp, expected RFC 3339", name)
+			}
+			*dest = &value
+		}
+	}
+
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return payload.ListOrders{}, errors.New("invalid limit, expected a positive integer")
+		}
+		query.Limit = limit
+	}
+
+	if raw := ctx.Query("product_id"); raw != "" {
+		productID, err := uuid.Parse(raw)
+		if err != nil {
+			return payload.ListOrders{}, errors.New("invalid product ID")
+		}
+		query.ProductID = &productID
+	}
+
+	return query, nil
 }
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"time"
+
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/log"
 	"github.com/google/uuid"
@@ -10,9 +14,17 @@ import (
 )
 
 type PackSizesService interface {
-	GetAllPackSizes() ([]models.PackSize, error)
+	GetAllPackSizes(productID uuid.UUID, status payload.PackSizeStatus) ([]models.PackSize, error)
 	CreatePackSize(packSize models.PackSize) (models.PackSize, error)
-	UpdatePackSize(packSize models.PackSize) (models.PackSize, error)
+	UpdatePackSize(update payload.UpdatePackSize) (models.PackSize, error)
+	SetPackSizeStock(packSizeID uuid.UUID, stock *int) (models.PackSize, error)
+	SetPackSizeDimensions(packSize models.PackSize) (models.PackSize, error)
+	DeletePackSize(packSizeID uuid.UUID) error
+	GetPackSizesAt(productID uuid.UUID, at time.Time) ([]models.PackSize, error)
+	GetAllCatalogs(productID uuid.UUID) ([]models.PackSizeCatalog, error)
+	ScheduleCatalog(productID uuid.UUID, effectiveFrom time.Time, sizes []int) (models.PackSizeCatalog, error)
+	ImportPackSizes(productID uuid.UUID, r io.Reader) (payload.PackSizesImport, error)
+	ReplacePackSizes(productID uuid.UUID, sizes []int) (models.PackSizeCatalog, error)
 }
 
 type PackSizesHandler struct {
@@ -28,16 +40,26 @@ func NewPackSizesHandler(service PackSizesService) *PackSizesHandler {
 // CreatePackSize godoc
 //
 //	@Summary		Create a new pack size
-//	@Description	Add a new pack size to the system
+//	@Description	Add a new pack size to a product, or to the default product on /pack-sizes
 //	@Tags			PackSizes
 //	@Accept			json
 //	@Produce		json
+//	@Param			product_id	path		string					true	"The ID of the product"
 //	@Param			packSize	body		payload.CreatePackSize	true	"The pack size to create"
 //	@Success		201			{object}	models.PackSize
 //	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
 //	@Failure		500			{object}	payload.ErrorResponse
 //	@Router			/pack-sizes [post]
+//	@Router			/products/{product_id}/pack-sizes [post]
 func (h *PackSizesHandler) CreatePackSize(ctx fiber.Ctx) error {
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
 	input, err := utils.UnmarshalRequest[payload.CreatePackSize](ctx)
 	if err != nil {
 		return ctx.
@@ -46,10 +68,29 @@ func (h *PackSizesHandler) CreatePackSize(ctx fiber.Ctx) error {
 	}
 
 	createdPackSize, err := h.service.CreatePackSize(models.PackSize{
-		ID:   uuid.New(),
-		Size: input.Size,
+		ID:            uuid.New(),
+		ProductID:     productID,
+		Size:          input.Size,
+		Stock:         input.Stock,
+		UnitCostCents: input.UnitCostCents,
+		WeightGrams:   input.WeightGrams,
+		LengthMm:      input.LengthMm,
+		WidthMm:       input.WidthMm,
+		HeightMm:      input.HeightMm,
 	})
 	if err != nil {
+		if errors.Is(err, payload.ErrInvalidPackSize) || errors.Is(err, payload.ErrInvalidStock) || errors.Is(err, payload.ErrInvalidCost) || dimensionsError(err) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
 		return ctx.
 			Status(fiber.StatusInternalServerError).
 			JSON(payload.ErrorResponse{Message: "failed to create pack size"})
@@ -58,10 +99,104 @@ func (h *PackSizesHandler) CreatePackSize(ctx fiber.Ctx) error {
 	return ctx.Status(fiber.StatusCreated).JSON(createdPackSize)
 }
 
+// ImportPackSizes godoc
+//
+//	@Summary		Import pack sizes from a CSV
+//	@Description	Create a pack size for every row of a CSV with a size column and optional stock, unit_cost_cents, weight_grams, length_mm, width_mm and height_mm columns, sent as the body or as the file field of a form. Nothing is imported when any row has errors
+//	@Tags			PackSizes
+//	@Accept			text/csv
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			product_id	path		string	true	"The ID of the product"
+//	@Param			file		formData	file	false	"The CSV, when sent as a form"
+//	@Success		201			{object}	payload.PackSizesImport
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		422			{object}	payload.PackSizesImport	"Rows with errors, nothing was imported"
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/import [post]
+//	@Router			/products/{product_id}/pack-sizes/import [post]
+func (h *PackSizesHandler) ImportPackSizes(ctx fiber.Ctx) error {
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	file, err := utils.UploadedFile(ctx, "file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "missing CSV file"})
+	}
+	defer file.Close()
+
+	result, err := h.service.ImportPackSizes(productID, file)
+	if err != nil {
+		if errors.Is(err, payload.ErrEmptyImport) || errors.Is(err, payload.ErrTooManyImportRows) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
+		log.Errorf("Failed to import pack sizes: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to import pack sizes"})
+	}
+
+	if len(result.Errors) > 0 {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(result)
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(result)
+}
+
+// ReplacePackSizes godoc
+//
+//	@Summary		Replace every pack size
+//	@Description	Replace the pack sizes of a product with the given sizes in a single transaction and publish them as a catalog effective immediately. Sizes the product already has keep their stock, cost and dimensions, and the others are deleted
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		string						true	"The ID of the product"
+//	@Param			packSizes	body		payload.ReplacePackSizes	true	"The sizes of the new catalog"
+//	@Success		200			{object}	models.PackSizeCatalog
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/pack-sizes [put]
+//	@Router			/products/{product_id}/pack-sizes [put]
+func (h *PackSizesHandler) ReplacePackSizes(ctx fiber.Ctx) error {
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	input, err := utils.UnmarshalRequest[payload.ReplacePackSizes](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	catalog, err := h.service.ReplacePackSizes(productID, input.Sizes)
+	if err != nil {
+		if errors.Is(err, payload.ErrNoSizes) ||
+			errors.Is(err, payload.ErrTooManySizes) ||
+			errors.Is(err, payload.ErrDuplicateSize) ||
+			errors.Is(err, payload.ErrInvalidPackSize) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
+		log.Errorf("Failed to replace pack sizes: %v", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(payload.ErrorResponse{Message: "failed to replace pack sizes"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(catalog)
+}
+
 // UpdatePackSize godoc
 //
 //	@Summary		Update an existing pack size
-//	@Description	Update the size of an existing pack size
+//	@Description	Update the size or unit cost of an existing pack size and/or activate or deactivate it
 //	@Tags			PackSizes
 //	@Accept			json
 //	@Produce		json
@@ -69,6 +204,7 @@ func (h *PackSizesHandler) CreatePackSize(ctx fiber.Ctx) error {
 //	@Param			packSize	body		payload.UpdatePackSize	true	"The updated pack size data"
 //	@Success		200			{object}	models.PackSize
 //	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
 //	@Failure		500			{object}	payload.ErrorResponse
 //	@Router			/pack-sizes/{pack_size_id} [put]
 func (h *PackSizesHandler) UpdatePackSize(ctx fiber.Ctx) error {
@@ -77,6 +213,12 @@ func (h *PackSizesHandler) UpdatePackSize(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid request body"})
 	}
 
+	if input.Size <= 0 && input.Active == nil && input.UnitCostCents == nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "a positive size, the active flag or the unit cost must be given"})
+	}
+
 	packSizeID, err := uuid.Parse(ctx.Params("pack_size_id"))
 	if err != nil {
 		log.Error("invalid pack size ID:", err)
@@ -86,11 +228,22 @@ func (h *PackSizesHandler) UpdatePackSize(ctx fiber.Ctx) error {
 			JSON(payload.ErrorResponse{Message: "invalid pack size ID"})
 	}
 
-	updatedPackSize, err := h.service.UpdatePackSize(models.PackSize{
-		ID:   packSizeID,
-		Size: input.Size,
-	})
+	input.ID = packSizeID
+
+	updatedPackSize, err := h.service.UpdatePackSize(input)
 	if err != nil {
+		if errors.Is(err, payload.ErrInvalidPackSize) || errors.Is(err, payload.ErrInvalidCost) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		if errors.Is(err, payload.ErrPackSizeNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "pack size not found"})
+		}
+
 		log.Error("failed to update pack size:", err)
 
 		return ctx.
@@ -101,19 +254,203 @@ func (h *PackSizesHandler) UpdatePackSize(ctx fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(updatedPackSize)
 }
 
+// SetPackSizeStock godoc
+//
+//	@Summary		Set the stock of a pack size
+//	@Description	Set how many packs of a size are in stock, or stop tracking it with a null stock so the size is always available
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			pack_size_id	path		string						true	"The ID of the pack size"
+//	@Param			stock			body		payload.UpdatePackSizeStock	true	"The packs in stock"
+//	@Success		200				{object}	models.PackSize
+//	@Failure		400				{object}	payload.ErrorResponse
+//	@Failure		404				{object}	payload.ErrorResponse
+//	@Failure		500				{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/{pack_size_id}/stock [put]
+func (h *PackSizesHandler) SetPackSizeStock(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.UpdatePackSizeStock](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	packSizeID, err := uuid.Parse(ctx.Params("pack_size_id"))
+	if err != nil {
+		log.Error("invalid pack size ID:", err)
+
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid pack size ID"})
+	}
+
+	packSize, err := h.service.SetPackSizeStock(packSizeID, input.Stock)
+	if err != nil {
+		if errors.Is(err, payload.ErrInvalidStock) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		if errors.Is(err, payload.ErrPackSizeNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "pack size not found"})
+		}
+
+		log.Error("failed to set pack size stock:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to set pack size stock"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(packSize)
+}
+
+// SetPackSizeDimensions godoc
+//
+//	@Summary		Set the weight and dimensions of a pack size
+//	@Description	Set the weight in grams and the outer dimensions in millimetres of a single pack, used to split orders into shipments
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			pack_size_id	path		string								true	"The ID of the pack size"
+//	@Param			dimensions		body		payload.UpdatePackSizeDimensions	true	"The weight and dimensions of a pack"
+//	@Success		200				{object}	models.PackSize
+//	@Failure		400				{object}	payload.ErrorResponse
+//	@Failure		404				{object}	payload.ErrorResponse
+//	@Failure		500				{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/{pack_size_id}/dimensions [put]
+func (h *PackSizesHandler) SetPackSizeDimensions(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.UpdatePackSizeDimensions](ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	packSizeID, err := uuid.Parse(ctx.Params("pack_size_id"))
+	if err != nil {
+		log.Error("invalid pack size ID:", err)
+
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid pack size ID"})
+	}
+
+	packSize, err := h.service.SetPackSizeDimensions(models.PackSize{
+		ID:          packSizeID,
+		WeightGrams: input.WeightGrams,
+		LengthMm:    input.LengthMm,
+		WidthMm:     input.WidthMm,
+		HeightMm:    input.HeightMm,
+	})
+	if err != nil {
+		if dimensionsError(err) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		if errors.Is(err, payload.ErrPackSizeNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "pack size not found"})
+		}
+
+		log.Error("failed to set pack size dimensions:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to set pack size dimensions"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(packSize)
+}
+
+// DeletePackSize godoc
+//
+//	@Summary		Delete a pack size
+//	@Description	Delete a pack size for good, catalog versions and orders that used it keep their own snapshot of it
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			pack_size_id	path	string	true	"The ID of the pack size to delete"
+//	@Success		204
+//	@Failure		400	{object}	payload.ErrorResponse
+//	@Failure		404	{object}	payload.ErrorResponse
+//	@Failure		500	{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/{pack_size_id} [delete]
+func (h *PackSizesHandler) DeletePackSize(ctx fiber.Ctx) error {
+	packSizeID, err := uuid.Parse(ctx.Params("pack_size_id"))
+	if err != nil {
+		log.Error("invalid pack size ID:", err)
+
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid pack size ID"})
+	}
+
+	if err := h.service.DeletePackSize(packSizeID); err != nil {
+		if errors.Is(err, payload.ErrPackSizeNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "pack size not found"})
+		}
+
+		log.Error("failed to delete pack size:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to delete pack size"})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
 // GetAllPackSizes godoc
 //
 //	@Summary		Get all pack sizes
-//	@Description	Retrieve a list of all available pack sizes
+//	@Description	Retrieve a list of the pack sizes of a product filtered by status, or the catalog that was effective at the given time
 //	@Tags			PackSizes
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		models.PackSize
-//	@Failure		500	{object}	payload.ErrorResponse
+//	@Param			product_id	path		string	true	"The ID of the product"
+//	@Param			status		query		string	false	"Filter by status: active, inactive or all (default)"
+//	@Param			at			query		string	false	"RFC 3339 timestamp to get the catalog effective at"
+//	@Success		200			{array}		models.PackSize
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
 //	@Router			/pack-sizes [get]
+//	@Router			/products/{product_id}/pack-sizes [get]
 func (h *PackSizesHandler) GetAllPackSizes(ctx fiber.Ctx) error {
-	packSizes, err := h.service.GetAllPackSizes()
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	var packSizes []models.PackSize
+
+	if rawAt := ctx.Query("at"); rawAt != "" {
+		at, parseErr := time.Parse(time.RFC3339, rawAt)
+		if parseErr != nil {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: "invalid at timestamp, expected RFC 3339"})
+		}
+
+		packSizes, err = h.service.GetPackSizesAt(productID, at)
+	} else {
+		status := payload.PackSizeStatus(ctx.Query("status", string(payload.PackSizeStatusAll)))
+		packSizes, err = h.service.GetAllPackSizes(productID, status)
+	}
 	if err != nil {
+		if errors.Is(err, payload.ErrInvalidPackSizeStatus) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
 		return ctx.
 			Status(fiber.StatusInternalServerError).
 			JSON(payload.ErrorResponse{Message: "failed to retrieve pack sizes"})
@@ -121,3 +458,104 @@ func (h *PackSizesHandler) GetAllPackSizes(ctx fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(packSizes)
 }
+
+// ScheduleCatalog godoc
+//
+//	@Summary		Schedule a pack size catalog
+//	@Description	Create a catalog version of a product with the given active sizes that becomes effective at the given time. Pack sizes changed before then are changed in the scheduled version too, so it never brings back a deleted, deactivated or repriced size
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		string					true	"The ID of the product"
+//	@Param			catalog		body		payload.ScheduleCatalog	true	"The catalog to schedule"
+//	@Success		201			{object}	models.PackSizeCatalog
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/catalogs [post]
+//	@Router			/products/{product_id}/pack-sizes/catalogs [post]
+func (h *PackSizesHandler) ScheduleCatalog(ctx fiber.Ctx) error {
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	input, err := utils.UnmarshalRequest[payload.ScheduleCatalog](ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	catalog, err := h.service.ScheduleCatalog(productID, input.EffectiveFrom, input.Sizes)
+	if err != nil {
+		if errors.Is(err, payload.ErrCatalogEffectiveFromPast) {
+			return ctx.
+				Status(fiber.StatusBadRequest).
+				JSON(payload.ErrorResponse{Message: "effective_from must not be in the past"})
+		}
+
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
+		if errors.Is(err, payload.ErrInactivePackSize) {
+			return ctx.
+				Status(fiber.StatusConflict).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Error("failed to schedule catalog:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to schedule catalog"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(catalog)
+}
+
+// GetAllCatalogs godoc
+//
+//	@Summary		Get all pack size catalogs
+//	@Description	Retrieve every catalog version of a product, newest effective date first
+//	@Tags			PackSizes
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		string	true	"The ID of the product"
+//	@Success		200			{array}		models.PackSizeCatalog
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/pack-sizes/catalogs [get]
+//	@Router			/products/{product_id}/pack-sizes/catalogs [get]
+func (h *PackSizesHandler) GetAllCatalogs(ctx fiber.Ctx) error {
+	productID, err := productIDParam(ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	catalogs, err := h.service.GetAllCatalogs(productID)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to retrieve catalogs"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(catalogs)
+}
+
+// dimensionsError reports whether the weight or one of the outer dimensions
+// of a pack was refused.
+func dimensionsError(err error) bool {
+	return errors.Is(err, payload.ErrInvalidWeight) ||
+		errors.Is(err, payload.ErrInvalidLength) ||
+		errors.Is(err, payload.ErrInvalidWidth) ||
+		errors.Is(err, payload.ErrInvalidHeight)
+}
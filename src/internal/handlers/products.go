@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/utils"
+)
+
+type ProductsService interface {
+	GetAllProducts() ([]models.Product, error)
+	GetProduct(productID uuid.UUID) (models.Product, error)
+	CreateProduct(product models.Product) (models.Product, error)
+	UpdateProduct(product models.Product) (models.Product, error)
+	DeleteProduct(productID uuid.UUID) error
+}
+
+type ProductsHandler struct {
+	service ProductsService
+}
+
+func NewProductsHandler(service ProductsService) *ProductsHandler {
+	return &ProductsHandler{
+		service: service,
+	}
+}
+
+// CreateProduct godoc
+//
+//	@Summary		Create a new product
+//	@Description	Add a new product that can have its own pack sizes
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product	body		payload.CreateProduct	true	"The product to create"
+//	@Success		201		{object}	models.Product
+//	@Failure		400		{object}	payload.ErrorResponse
+//	@Failure		409		{object}	payload.ErrorResponse
+//	@Failure		500		{object}	payload.ErrorResponse
+//	@Router			/products [post]
+func (h *ProductsHandler) CreateProduct(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.CreateProduct](ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	createdProduct, err := h.service.CreateProduct(models.Product{
+		ID:   uuid.New(),
+		Name: input.Name,
+		SKU:  input.SKU,
+	})
+	if err != nil {
+		if status, productErr := productError(err); productErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: productErr.Error()})
+		}
+
+		log.Error("failed to create product:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to create product"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(createdProduct)
+}
+
+// GetProduct godoc
+//
+//	@Summary		Get a product by ID
+//	@Description	Retrieve the details of a product using its ID
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		string	true	"The ID of the product to retrieve"
+//	@Success		200			{object}	models.Product
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/products/{product_id} [get]
+func (h *ProductsHandler) GetProduct(ctx fiber.Ctx) error {
+	productID, err := uuid.Parse(ctx.Params("product_id"))
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	product, err := h.service.GetProduct(productID)
+	if err != nil {
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to retrieve product"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(product)
+}
+
+// GetAllProducts godoc
+//
+//	@Summary		Get all products
+//	@Description	Retrieve a list of all products
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.Product
+//	@Failure		500	{object}	payload.ErrorResponse
+//	@Router			/products [get]
+func (h *ProductsHandler) GetAllProducts(ctx fiber.Ctx) error {
+	products, err := h.service.GetAllProducts()
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to retrieve products"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(products)
+}
+
+// UpdateProduct godoc
+//
+//	@Summary		Update an existing product
+//	@Description	Update the name and SKU of an existing product
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		string					true	"The ID of the product to update"
+//	@Param			product		body		payload.UpdateProduct	true	"The updated product data"
+//	@Success		200			{object}	models.Product
+//	@Failure		400			{object}	payload.ErrorResponse
+//	@Failure		404			{object}	payload.ErrorResponse
+//	@Failure		409			{object}	payload.ErrorResponse
+//	@Failure		500			{object}	payload.ErrorResponse
+//	@Router			/products/{product_id} [put]
+func (h *ProductsHandler) UpdateProduct(ctx fiber.Ctx) error {
+	input, err := utils.UnmarshalRequest[payload.UpdateProduct](ctx)
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid request body"})
+	}
+
+	productID, err := uuid.Parse(ctx.Params("product_id"))
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	updatedProduct, err := h.service.UpdateProduct(models.Product{
+		ID:   productID,
+		Name: input.Name,
+		SKU:  input.SKU,
+	})
+	if err != nil {
+		if errors.Is(err, payload.ErrProductNotFound) {
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "product not found"})
+		}
+
+		if status, productErr := productError(err); productErr != nil {
+			return ctx.Status(status).JSON(payload.ErrorResponse{Message: productErr.Error()})
+		}
+
+		log.Error("failed to update product:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to update product"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(updatedProduct)
+}
+
+// DeleteProduct godoc
+//
+//	@Summary		Delete a product
+//	@Description	Delete a product together with its pack sizes, as long as it was never ordered
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path	string	true	"The ID of the product to delete"
+//	@Success		204
+//	@Failure		400	{object}	payload.ErrorResponse
+//	@Failure		404	{object}	payload.ErrorResponse
+//	@Failure		409	{object}	payload.ErrorResponse
+//	@Failure		500	{object}	payload.ErrorResponse
+//	@Router			/products/{product_id} [delete]
+func (h *ProductsHandler) DeleteProduct(ctx fiber.Ctx) error {
+	productID, err := uuid.Parse(ctx.Params("product_id"))
+	if err != nil {
+		return ctx.
+			Status(fiber.StatusBadRequest).
+			JSON(payload.ErrorResponse{Message: "invalid product ID"})
+	}
+
+	if err := h.service.DeleteProduct(productID); err != nil {
+		switch {
+		case errors.Is(err, payload.ErrProductNotFound):
+			return ctx.
+				Status(fiber.StatusNotFound).
+				JSON(payload.ErrorResponse{Message: "product not found"})
+		case errors.Is(err, payload.ErrProductInUse), errors.Is(err, payload.ErrDefaultProduct):
+			return ctx.
+				Status(fiber.StatusConflict).
+				JSON(payload.ErrorResponse{Message: err.Error()})
+		}
+
+		log.Error("failed to delete product:", err)
+
+		return ctx.
+			Status(fiber.StatusInternalServerError).
+			JSON(payload.ErrorResponse{Message: "failed to delete product"})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// productIDParam reads the product from the route, falling back to the
+// default product on routes that aren't scoped to a product.
+func productIDParam(ctx fiber.Ctx) (uuid.UUID, error) {
+	rawProductID := ctx.Params("product_id")
+	if rawProductID == "" {
+		return models.DefaultProductID, nil
+	}
+
+	return uuid.Parse(rawProductID)
+}
+
+// productError returns the status and the error reported to the client for
+// the errors saving a product can fail with, or a nil error for any other
+// error.
+func productError(err error) (int, error) {
+	switch {
+	case errors.Is(err, payload.ErrMissingProductFields):
+		return fiber.StatusBadRequest, payload.ErrMissingProductFields
+	case errors.Is(err, payload.ErrProductFieldsTooLong):
+		return fiber.StatusBadRequest, payload.ErrProductFieldsTooLong
+	case errors.Is(err, payload.ErrDuplicateSKU):
+		return fiber.StatusConflict, payload.ErrDuplicateSKU
+	}
+
+	return fiber.StatusInternalServerError, nil
+}
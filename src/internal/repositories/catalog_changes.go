@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// CatalogChangesChannel is the PostgreSQL notification channel catalog
+// changes are sent on.
+const CatalogChangesChannel = "pack_size_catalog_changes"
+
+type Listener interface {
+	Listen(ctx context.Context, channel string, onConnect func(), handle func(payload string)) error
+}
+
+type CatalogChangesRepository struct {
+	db Listener
+}
+
+func NewCatalogChangesRepository(db Listener) *CatalogChangesRepository {
+	return &CatalogChangesRepository{
+		db: db,
+	}
+}
+
+// publishCatalogChange sends the change to every replica. Repositories
+// send it through the transaction that saves the change, so it's only
+// delivered once the change is committed.
+func publishCatalogChange(q database.Querier, change models.CatalogChange) error {
+	encoded, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	return database.Notify(q, CatalogChangesChannel, string(encoded))
+}
+
+// ListenCatalogChanges calls handle with every catalog change published by
+// any replica until ctx is done. Changes may have been missed whenever the
+// listening connection was lost, so a change of any product is reported
+// every time listening starts again.
+func (r *CatalogChangesRepository) ListenCatalogChanges(ctx context.Context, handle func(change models.CatalogChange)) error {
+	connected := false
+	onConnect := func() {
+		if connected {
+			handle(models.CatalogChange{})
+		}
+		connected = true
+	}
+
+	return r.db.Listen(ctx, CatalogChangesChannel, onConnect, func(payload string) {
+		var change models.CatalogChange
+		if err := json.Unmarshal([]byte(payload), &change); err != nil {
+			slog.Warn("Skipping malformed catalog change", "payload", payload, "error", err)
+			return
+		}
+
+		handle(change)
+	})
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// Runs against the docker-compose Postgres, or the one set up through the
+// DATABASE_* environment variables:
+//
+//	docker-compose up -d postgres
+//	go test -tags integration ./src/internal/repositories/...
+func TestCatalogChangesRepository_PublishAndListen(t *testing.T) {
+	var cfg config.Config
+	if err := cleanenv.ReadConfig("../../config/config.yml", &cfg); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	db, err := database.NewDatabase(cfg.Database)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	listener := NewCatalogChangesRepository(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	received := make(chan models.CatalogChange, 1)
+	go func() {
+		_ = listener.ListenCatalogChanges(ctx, func(change models.CatalogChange) {
+			received <- change
+		})
+	}()
+
+	change := models.CatalogChange{ProductID: uuid.New(), Origin: uuid.New()}
+
+	// Keep publishing until the listener is subscribed
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if err := publishCatalogChange(db, change); err != nil {
+			t.Fatalf("failed to publish catalog change: %v", err)
+		}
+
+		select {
+		case got := <-received:
+			if got != change {
+				t.Fatalf("expected change %+v, got %+v", change, got)
+			}
+			return
+		case <-ticker.C:
+		case <-ctx.Done():
+			t.Fatal("catalog change wasn't received")
+		}
+	}
+}
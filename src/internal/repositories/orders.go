@@ -1,12 +1,32 @@
 package repositories
 
-import "github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
 
 type Database interface {
 	QueryWithScan(query string, dest interface{}, args ...any) error
+	Exec(query string, args ...any) error
 	Query(query string) error
+	WithTransaction(fn func(tx database.Querier) error) error
 }
 
+// selectOrders loads the orders together with their lines, aggregated as
+// JSON so a single query is enough.
+const selectOrders = `SELECT o.*, COALESCE((
+		SELECT jsonb_agg(l ORDER BY l.line_number) FROM order_lines l WHERE l.order_id = o.id
+	), '[]'::jsonb) AS lines
+	FROM orders o`
+
 type OrdersRepository struct {
 	db Database
 }
@@ -26,25 +46,467 @@ func (r *OrdersRepository) queryWithScan(query string, args ...any) (models.Orde
 	return dest, nil
 }
 
-func (r *OrdersRepository) GetAllOrders() ([]models.Order, error) {
-	query := "SELECT * FROM orders"
+// ListOrders returns up to query.Limit orders matching the filters, sorted
+// by the query's sort field and ID and starting after query.After.
+func (r *OrdersRepository) ListOrders(query payload.ListOrders) ([]models.Order, error) {
+	field, descending := query.SortField()
+	if !slices.Contains(payload.OrderSortFields, field) {
+		return nil, payload.ErrInvalidOrderSort
+	}
+
+	conditions, args := ordersFilter(query)
+
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		value, err := query.After.SortValue()
+		if err != nil {
+			return nil, payload.ErrInvalidCursor
+		}
+
+		args = append(args, value, query.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(o.%s, o.id) %s ($%d, $%d)", field, comparison, len(args)-1, len(args)))
+	}
 
-	var dest []models.Order
-	if err := r.db.QueryWithScan(query, &dest); err != nil {
+	args = append(args, query.Limit)
+	sql := fmt.Sprintf("%s%s ORDER BY o.%s %s, o.id %s LIMIT $%d",
+		selectOrders, whereClause(conditions), field, direction, direction, len(args))
+
+	dest := []models.Order{}
+	if err := r.db.QueryWithScan(sql, &dest, args...); err != nil {
 		return nil, err
 	}
 
 	return dest, nil
 }
 
+// CountOrders counts every order matching the filters of the query,
+// regardless of its cursor and limit.
+func (r *OrdersRepository) CountOrders(query payload.ListOrders) (int, error) {
+	conditions, args := ordersFilter(query)
+
+	var count int
+	if err := r.db.QueryWithScan("SELECT count(*) FROM orders o"+whereClause(conditions), &count, args...); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ordersFilter returns the conditions on the orders, aliased as o, that the
+// filters of the query add up to and their arguments.
+func ordersFilter(query payload.ListOrders) ([]string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.MinItemsCount != nil {
+		add("o.items_count >= $%d", *query.MinItemsCount)
+	}
+	if query.MaxItemsCount != nil {
+		add("o.items_count <= $%d", *query.MaxItemsCount)
+	}
+	if query.CreatedFrom != nil {
+		add("o.created_at >= $%d", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		add("o.created_at < $%d", *query.CreatedTo)
+	}
+	if query.ProductID != nil {
+		add("EXISTS (SELECT 1 FROM order_lines l WHERE l.order_id = o.id AND l.product_id = $%d)", *query.ProductID)
+	}
+	if query.PackSize != nil {
+		add("EXISTS (SELECT 1 FROM order_lines l WHERE l.order_id = o.id AND l.packs @> jsonb_build_array(jsonb_build_object('pack_size', $%d::int)))", *query.PackSize)
+	}
+
+	return conditions, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// SaveOrder inserts the order and its lines and takes the packs out of stock
+// in a single transaction. It fails with payload.ErrInsufficientStock,
+// saving nothing, when a pack size no longer has enough packs in stock.
 func (r *OrdersRepository) SaveOrder(order models.Order) (models.Order, error) {
-	query := "INSERT INTO orders (id, items_count, pack_setup) VALUES ($1, $2, $3) RETURNING *"
+	var dest models.Order
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		return insertOrder(tx, order, &dest)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return dest, nil
+}
+
+// SaveOrders saves the orders like SaveOrder, all of them in a single
+// transaction, so either every order is saved or none is.
+func (r *OrdersRepository) SaveOrders(orders []models.Order) ([]models.Order, error) {
+	dest := make([]models.Order, len(orders))
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		for i, order := range orders {
+			if err := insertOrder(tx, order, &dest[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dest, nil
+}
+
+// SaveOrderWithIdempotencyKey saves the order like SaveOrder and records the
+// idempotency key it was created with in the same transaction, dropping the
+// keys that expired by the time the new one was created. A key that's still
+// in use fails the insert with a unique violation and nothing is saved.
+func (r *OrdersRepository) SaveOrderWithIdempotencyKey(order models.Order, key models.IdempotencyKey) (models.Order, error) {
+	var dest models.Order
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		if err := tx.Exec("DELETE FROM idempotency_keys WHERE expires_at <= $1", key.CreatedAt); err != nil {
+			return err
+		}
+
+		if err := insertOrder(tx, order, &dest); err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"INSERT INTO idempotency_keys (key, request_hash, order_id, response, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+			key.Key,
+			key.RequestHash,
+			key.OrderID,
+			key.Response,
+			key.CreatedAt,
+			key.ExpiresAt,
+		)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return dest, nil
+}
+
+// FetchIdempotencyKey returns the key unless it expired by the given time.
+func (r *OrdersRepository) FetchIdempotencyKey(key string, at time.Time) (models.IdempotencyKey, error) {
+	var dest models.IdempotencyKey
+	err := r.db.QueryWithScan("SELECT * FROM idempotency_keys WHERE key = $1 AND expires_at > $2", &dest, key, at)
+	if err != nil {
+		return models.IdempotencyKey{}, err
+	}
+
+	return dest, nil
+}
+
+func insertOrder(tx database.Querier, order models.Order, dest *models.Order) error {
+	orderQuery := `INSERT INTO orders (id, product_id, items_count, pack_setup, packs, shipped_items, overshoot, total_packs, catalog, catalog_id, strategy, handling_cost_cents, total_cost_cents, total_weight_grams, total_volume_mm3, shipments, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+
+	err := tx.Exec(
+		orderQuery,
+		order.ID,
+		order.ProductID,
+		order.ItemsCount,
+		order.PackSetup,
+		order.Packs,
+		order.ShippedItems,
+		order.Overshoot,
+		order.TotalPacks,
+		order.Catalog,
+		order.CatalogID,
+		order.Strategy,
+		order.HandlingCostCents,
+		order.TotalCostCents,
+		order.TotalWeightGrams,
+		order.TotalVolumeMm3,
+		order.Shipments,
+		order.Status,
+		order.CreatedAt,
+		order.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOrderLines(tx, order); err != nil {
+		return err
+	}
+
+	if err := takeStock(tx, packQuantities(order)); err != nil {
+		return err
+	}
+
+	return tx.QueryWithScan(selectOrders+" WHERE o.id = $1", dest, order.ID)
+}
+
+// UpdateOrderStatus moves the order to the change's status and records the
+// change in the order's history in a single transaction. It fails with
+// payload.ErrInvalidStatusTransition when the order is no longer in the
+// status the change is from. A cancelled order's packs go back in stock.
+func (r *OrdersRepository) UpdateOrderStatus(change models.OrderStatusChange) (models.Order, error) {
+	statusQuery := `UPDATE orders SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4 RETURNING id`
+	historyQuery := `INSERT INTO order_status_history (id, order_id, from_status, to_status, changed_by, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	var dest models.Order
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		var updated []uuid.UUID
+		err := tx.QueryWithScan(statusQuery, &updated, change.ToStatus, change.ChangedAt, change.OrderID, change.FromStatus)
+		if err != nil {
+			return err
+		}
+
+		if len(updated) == 0 {
+			return payload.ErrInvalidStatusTransition
+		}
+
+		err = tx.Exec(
+			historyQuery,
+			change.ID,
+			change.OrderID,
+			change.FromStatus,
+			change.ToStatus,
+			change.ChangedBy,
+			change.ChangedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryWithScan(selectOrders+" WHERE o.id = $1", &dest, change.OrderID); err != nil {
+			return err
+		}
+
+		if change.ToStatus != models.OrderStatusCancelled {
+			return nil
+		}
+
+		return returnStock(tx, packQuantities(dest))
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return dest, nil
+}
+
+// AmendOrder replaces the breakdown and lines of the order and records the
+// previous order as a revision in a single transaction, handing its packs
+// back to stock and taking the new ones. It fails with
+// payload.ErrOrderChanged when the order was updated since the revision's
+// previous order was read, and with payload.ErrInsufficientStock when the
+// stock can't cover the new packs.
+func (r *OrdersRepository) AmendOrder(order models.Order, revision models.OrderRevision) (models.Order, error) {
+	orderQuery := `UPDATE orders SET product_id = $1, items_count = $2, pack_setup = $3, packs = $4, shipped_items = $5, overshoot = $6, total_packs = $7, catalog = $8, catalog_id = $9, handling_cost_cents = $10, total_cost_cents = $11, total_weight_grams = $12, total_volume_mm3 = $13, shipments = $14, updated_at = $15
+		WHERE id = $16 AND updated_at = $17 RETURNING id`
+	// The order's row is locked by the update, so revisions of the same
+	// order are numbered one at a time
+	revisionQuery := `INSERT INTO order_revisions (id, order_id, revision, previous, changed_by, created_at)
+		VALUES ($1, $2, (SELECT count(*) + 1 FROM order_revisions WHERE order_id = $2), $3, $4, $5)`
+
+	var dest models.Order
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		var updated []uuid.UUID
+		err := tx.QueryWithScan(
+			orderQuery,
+			&updated,
+			order.ProductID,
+			order.ItemsCount,
+			order.PackSetup,
+			order.Packs,
+			order.ShippedItems,
+			order.Overshoot,
+			order.TotalPacks,
+			order.Catalog,
+			order.CatalogID,
+			order.HandlingCostCents,
+			order.TotalCostCents,
+			order.TotalWeightGrams,
+			order.TotalVolumeMm3,
+			order.Shipments,
+			order.UpdatedAt,
+			order.ID,
+			revision.Previous.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(updated) == 0 {
+			return payload.ErrOrderChanged
+		}
+
+		err = tx.Exec(revisionQuery, revision.ID, order.ID, revision.Previous, revision.ChangedBy, revision.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := returnStock(tx, packQuantities(revision.Previous)); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM order_lines WHERE order_id = $1", order.ID); err != nil {
+			return err
+		}
+
+		if err := insertOrderLines(tx, order); err != nil {
+			return err
+		}
+
+		if err := takeStock(tx, packQuantities(order)); err != nil {
+			return err
+		}
+
+		return tx.QueryWithScan(selectOrders+" WHERE o.id = $1", &dest, order.ID)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return dest, nil
+}
+
+// GetOrderRevisions returns the revisions of the order from the oldest.
+func (r *OrdersRepository) GetOrderRevisions(orderID string) ([]models.OrderRevision, error) {
+	query := "SELECT * FROM order_revisions WHERE order_id = $1 ORDER BY revision"
+
+	dest := []models.OrderRevision{}
+	if err := r.db.QueryWithScan(query, &dest, orderID); err != nil {
+		return nil, err
+	}
 
-	return r.queryWithScan(query, order.ID, order.ItemsCount, order.PackSetup)
+	return dest, nil
+}
+
+// GetOrderStatusHistory returns the status changes of the order from the
+// oldest.
+func (r *OrdersRepository) GetOrderStatusHistory(orderID string) ([]models.OrderStatusChange, error) {
+	query := "SELECT * FROM order_status_history WHERE order_id = $1 ORDER BY changed_at, id"
+
+	dest := []models.OrderStatusChange{}
+	if err := r.db.QueryWithScan(query, &dest, orderID); err != nil {
+		return nil, err
+	}
+
+	return dest, nil
 }
 
 func (r *OrdersRepository) FetchOrder(orderID string) (models.Order, error) {
-	query := "SELECT * FROM orders WHERE id = $1"
+	query := selectOrders + " WHERE o.id = $1"
 
 	return r.queryWithScan(query, orderID)
 }
+
+func insertOrderLines(tx database.Querier, order models.Order) error {
+	lineQuery := `INSERT INTO order_lines (id, order_id, line_number, product_id, items_count, pack_setup, packs, shipped_items, overshoot, total_packs, catalog, catalog_id, total_cost_cents, total_weight_grams, total_volume_mm3)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+
+	for _, line := range order.Lines {
+		err := tx.Exec(
+			lineQuery,
+			line.ID,
+			order.ID,
+			line.LineNumber,
+			line.ProductID,
+			line.ItemsCount,
+			line.PackSetup,
+			line.Packs,
+			line.ShippedItems,
+			line.Overshoot,
+			line.TotalPacks,
+			line.Catalog,
+			line.CatalogID,
+			line.TotalCostCents,
+			line.TotalWeightGrams,
+			line.TotalVolumeMm3,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeStock takes the packs out of stock, failing with
+// payload.ErrInsufficientStock when a pack size doesn't have enough left.
+// Untracked stock is NULL and stays NULL.
+func takeStock(tx database.Querier, quantities map[uuid.UUID]int) error {
+	stockQuery := `UPDATE pack_sizes SET stock = stock - $1
+		WHERE id = $2 AND (stock IS NULL OR stock >= $1) RETURNING *`
+
+	for _, packSizeID := range sortedPackSizeIDs(quantities) {
+		var updated []models.PackSize
+		if err := tx.QueryWithScan(stockQuery, &updated, quantities[packSizeID], packSizeID); err != nil {
+			return err
+		}
+
+		if len(updated) == 0 {
+			return payload.ErrInsufficientStock
+		}
+	}
+
+	return nil
+}
+
+// returnStock puts the packs back in stock. Untracked stock is NULL and
+// stays NULL.
+func returnStock(tx database.Querier, quantities map[uuid.UUID]int) error {
+	stockQuery := "UPDATE pack_sizes SET stock = stock + $1 WHERE id = $2 AND stock IS NOT NULL"
+
+	for _, packSizeID := range sortedPackSizeIDs(quantities) {
+		if err := tx.Exec(stockQuery, quantities[packSizeID], packSizeID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// packQuantities sums the packs of every line of the order by pack size.
+// Packs recorded before pack size IDs were stored are left out.
+func packQuantities(order models.Order) map[uuid.UUID]int {
+	quantities := make(map[uuid.UUID]int)
+	for _, line := range order.Lines {
+		for _, pack := range line.Packs {
+			if pack.PackSizeID != uuid.Nil {
+				quantities[pack.PackSizeID] += pack.Quantity
+			}
+		}
+	}
+
+	return quantities
+}
+
+// sortedPackSizeIDs keeps the order the pack size rows are locked in stable
+// so concurrent orders can't deadlock each other.
+func sortedPackSizeIDs(quantities map[uuid.UUID]int) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+
+	return ids
+}
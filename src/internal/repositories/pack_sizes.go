@@ -1,6 +1,13 @@
 package repositories
 
-import "github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
 
 type PackSizesRepository struct {
 	db Database
@@ -12,35 +19,230 @@ func NewPackSizesRepository(db Database) *PackSizesRepository {
 	}
 }
 
-func (r *PackSizesRepository) CreatePackSize(packSize models.PackSize) (models.PackSize, error) {
-	query := "INSERT INTO pack_sizes (id, size) VALUES ($1, $2) RETURNING *"
+func (r *PackSizesRepository) GetAllPackSizes(productID string) ([]models.PackSize, error) {
+	query := "SELECT * FROM pack_sizes WHERE product_id = $1 ORDER BY size"
+
+	var dest []models.PackSize
+	if err := r.db.QueryWithScan(query, &dest, productID); err != nil {
+		return nil, err
+	}
+
+	return dest, nil
+}
+
+func (r *PackSizesRepository) GetPackSize(packSizeID string) (models.PackSize, error) {
+	query := "SELECT * FROM pack_sizes WHERE id = $1"
 
 	var dest models.PackSize
-	if err := r.db.QueryWithScan(query, &dest, packSize.ID, packSize.Size); err != nil {
+	if err := r.db.QueryWithScan(query, &dest, packSizeID); err != nil {
 		return models.PackSize{}, err
 	}
 
 	return dest, nil
 }
 
-func (r *PackSizesRepository) GetAllPackSizes() ([]models.PackSize, error) {
-	query := "SELECT * FROM pack_sizes"
+func (r *PackSizesRepository) SetPackSizeStock(packSizeID string, stock *int) (models.PackSize, error) {
+	query := "UPDATE pack_sizes SET stock = $1 WHERE id = $2 RETURNING *"
 
-	var dest []models.PackSize
-	if err := r.db.QueryWithScan(query, &dest); err != nil {
+	var dest models.PackSize
+	if err := r.db.QueryWithScan(query, &dest, stock, packSizeID); err != nil {
+		return models.PackSize{}, err
+	}
+
+	return dest, nil
+}
+
+func (r *PackSizesRepository) GetAllCatalogs(productID string) ([]models.PackSizeCatalog, error) {
+	query := `SELECT * FROM pack_size_catalogs
+		WHERE product_id = $1
+		ORDER BY effective_from DESC, created_at DESC`
+
+	var dest []models.PackSizeCatalog
+	if err := r.db.QueryWithScan(query, &dest, productID); err != nil {
 		return nil, err
 	}
 
 	return dest, nil
 }
 
-func (r *PackSizesRepository) UpdatePackSize(packSize models.PackSize) (models.PackSize, error) {
-	query := "UPDATE pack_sizes SET size = $1 WHERE id = $2 RETURNING *"
+func (r *PackSizesRepository) GetCatalogAt(productID string, at time.Time) (models.PackSizeCatalog, error) {
+	query := `SELECT * FROM pack_size_catalogs
+		WHERE product_id = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT 1`
 
-	var dest models.PackSize
-	if err := r.db.QueryWithScan(query, &dest, packSize.Size, packSize.ID); err != nil {
-		return models.PackSize{}, err
+	var dest models.PackSizeCatalog
+	if err := r.db.QueryWithScan(query, &dest, productID, at); err != nil {
+		return models.PackSizeCatalog{}, err
 	}
 
 	return dest, nil
 }
+
+// ChangePackSizes locks the product, reads the state of its pack sizes and
+// saves the changes made from it, all in a single transaction along with
+// the notification of the change. Changes of the same product wait for each
+// other, so every change is made from what the previous one saved and is
+// timed after it. The lock doesn't conflict with the key share lock taken
+// by foreign keys, so orders aren't held up by it. Deletes go first so a
+// size can move to a new row without breaking the unique constraint halfway
+// through.
+func (r *PackSizesRepository) ChangePackSizes(
+	productID uuid.UUID,
+	change func(models.ProductPackSizes) (models.PackSizeChanges, error),
+) (models.PackSizeChanges, error) {
+	var saved models.PackSizeChanges
+	err := r.db.WithTransaction(func(tx database.Querier) error {
+		var product models.Product
+		if err := tx.QueryWithScan("SELECT * FROM products WHERE id = $1 FOR NO KEY UPDATE", &product, productID); err != nil {
+			return err
+		}
+
+		state, err := r.getProductPackSizes(tx, productID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		changes, err := change(state)
+		if err != nil {
+			return err
+		}
+
+		for _, packSizeID := range changes.Deleted {
+			if err := tx.Exec("DELETE FROM pack_sizes WHERE id = $1", packSizeID); err != nil {
+				return err
+			}
+		}
+		saved.Deleted = changes.Deleted
+
+		for _, packSize := range changes.Updated {
+			var updated models.PackSize
+			err := tx.QueryWithScan(
+				`UPDATE pack_sizes SET size = $1, active = $2, unit_cost_cents = $3,
+					weight_grams = $4, length_mm = $5, width_mm = $6, height_mm = $7
+				WHERE id = $8 RETURNING *`,
+				&updated,
+				packSize.Size,
+				packSize.Active,
+				packSize.UnitCostCents,
+				packSize.WeightGrams,
+				packSize.LengthMm,
+				packSize.WidthMm,
+				packSize.HeightMm,
+				packSize.ID,
+			)
+			if err != nil {
+				return err
+			}
+
+			saved.Updated = append(saved.Updated, updated)
+		}
+
+		for _, packSize := range changes.Created {
+			var created models.PackSize
+			err := tx.QueryWithScan(
+				`INSERT INTO pack_sizes (id, product_id, size, active, stock, unit_cost_cents, weight_grams, length_mm, width_mm, height_mm)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *`,
+				&created,
+				packSize.ID,
+				packSize.ProductID,
+				packSize.Size,
+				packSize.Active,
+				packSize.Stock,
+				packSize.UnitCostCents,
+				packSize.WeightGrams,
+				packSize.LengthMm,
+				packSize.WidthMm,
+				packSize.HeightMm,
+			)
+			if err != nil {
+				return err
+			}
+
+			saved.Created = append(saved.Created, created)
+		}
+
+		for _, catalog := range changes.Catalogs {
+			var created models.PackSizeCatalog
+			err := tx.QueryWithScan(
+				"INSERT INTO pack_size_catalogs (id, product_id, effective_from, pack_sizes) VALUES ($1, $2, $3, $4) RETURNING *",
+				&created,
+				catalog.ID,
+				catalog.ProductID,
+				catalog.EffectiveFrom,
+				catalog.PackSizes,
+			)
+			if err != nil {
+				return err
+			}
+
+			saved.Catalogs = append(saved.Catalogs, created)
+		}
+
+		for _, catalog := range changes.Pending {
+			var updated models.PackSizeCatalog
+			err := tx.QueryWithScan(
+				"UPDATE pack_size_catalogs SET pack_sizes = $1 WHERE id = $2 RETURNING *",
+				&updated,
+				catalog.PackSizes,
+				catalog.ID,
+			)
+			if err != nil {
+				return err
+			}
+
+			saved.Pending = append(saved.Pending, updated)
+		}
+
+		if changes.Notification != nil {
+			saved.Notification = changes.Notification
+			return publishCatalogChange(tx, *changes.Notification)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.PackSizeChanges{}, err
+	}
+
+	return saved, nil
+}
+
+func (r *PackSizesRepository) getProductPackSizes(tx database.Querier, productID uuid.UUID, at time.Time) (models.ProductPackSizes, error) {
+	state := models.ProductPackSizes{At: at}
+
+	if err := tx.QueryWithScan("SELECT * FROM pack_sizes WHERE product_id = $1 ORDER BY size", &state.PackSizes, productID); err != nil {
+		return models.ProductPackSizes{}, err
+	}
+
+	var current []models.PackSizeCatalog
+	err := tx.QueryWithScan(
+		`SELECT * FROM pack_size_catalogs
+		WHERE product_id = $1 AND effective_from <= $2
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT 1`,
+		&current,
+		productID,
+		at,
+	)
+	if err != nil {
+		return models.ProductPackSizes{}, err
+	}
+	if len(current) > 0 {
+		state.Current = &current[0]
+	}
+
+	err = tx.QueryWithScan(
+		`SELECT * FROM pack_size_catalogs
+		WHERE product_id = $1 AND effective_from > $2
+		ORDER BY effective_from, created_at`,
+		&state.Pending,
+		productID,
+		at,
+	)
+	if err != nil {
+		return models.ProductPackSizes{}, err
+	}
+
+	return state, nil
+}
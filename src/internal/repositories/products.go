@@ -0,0 +1,57 @@
+package repositories
+
+import "github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+
+type ProductsRepository struct {
+	db Database
+}
+
+func NewProductsRepository(db Database) *ProductsRepository {
+	return &ProductsRepository{
+		db: db,
+	}
+}
+
+func (r *ProductsRepository) queryWithScan(query string, args ...any) (models.Product, error) {
+	var dest models.Product
+	if err := r.db.QueryWithScan(query, &dest, args...); err != nil {
+		return models.Product{}, err
+	}
+
+	return dest, nil
+}
+
+func (r *ProductsRepository) GetAllProducts() ([]models.Product, error) {
+	query := "SELECT * FROM products ORDER BY created_at"
+
+	var dest []models.Product
+	if err := r.db.QueryWithScan(query, &dest); err != nil {
+		return nil, err
+	}
+
+	return dest, nil
+}
+
+func (r *ProductsRepository) FetchProduct(productID string) (models.Product, error) {
+	query := "SELECT * FROM products WHERE id = $1"
+
+	return r.queryWithScan(query, productID)
+}
+
+func (r *ProductsRepository) CreateProduct(product models.Product) (models.Product, error) {
+	query := "INSERT INTO products (id, name, sku) VALUES ($1, $2, $3) RETURNING *"
+
+	return r.queryWithScan(query, product.ID, product.Name, product.SKU)
+}
+
+func (r *ProductsRepository) UpdateProduct(product models.Product) (models.Product, error) {
+	query := "UPDATE products SET name = $1, sku = $2 WHERE id = $3 RETURNING *"
+
+	return r.queryWithScan(query, product.Name, product.SKU, product.ID)
+}
+
+func (r *ProductsRepository) DeleteProduct(productID string) (models.Product, error) {
+	query := "DELETE FROM products WHERE id = $1 RETURNING *"
+
+	return r.queryWithScan(query, productID)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// maxBulkOrders caps the orders created by a single bulk request.
+const maxBulkOrders = 10000
+
+// CreateOrders creates a batch of orders. Every product's catalog and pack
+// sizes are read once for the whole batch and the packs of each order are
+// kept out of the stock available to the next ones.
+//
+// Atomic batches, the default, are saved in a single transaction once every
+// order was packed, and none is created when any of them fails. Partial
+// batches save each order on its own and report the ones that failed.
+func (s *OrdersService) CreateOrders(input payload.CreateOrders) (payload.BulkOrdersResult, error) {
+	mode := input.Mode
+	if mode == "" {
+		mode = payload.BulkModeAtomic
+	}
+	if mode != payload.BulkModeAtomic && mode != payload.BulkModePartial {
+		return payload.BulkOrdersResult{}, payload.ErrInvalidBulkMode
+	}
+
+	if len(input.Orders) == 0 {
+		return payload.BulkOrdersResult{}, payload.ErrEmptyBulk
+	}
+	if len(input.Orders) > maxBulkOrders {
+		return payload.BulkOrdersResult{}, payload.ErrTooManyBulkOrders
+	}
+
+	result := payload.BulkOrdersResult{
+		Mode:    mode,
+		Results: make([]payload.BulkOrderResult, len(input.Orders)),
+	}
+
+	read := cachedPacking(s.readPacking)
+	reserved := make(map[uuid.UUID]int)
+	now := time.Now().UTC()
+
+	orders := make([]models.Order, 0, len(input.Orders))
+	indexes := make([]int, 0, len(input.Orders))
+	for i, orderInput := range input.Orders {
+		result.Results[i].Index = i
+
+		// An order that fails halfway through its lines hands back the
+		// packs its first lines took
+		before := maps.Clone(reserved)
+		quote, err := s.quoteOrder(orderInput, reserved, read)
+		if err != nil {
+			reserved = before
+			result.Results[i].Status = payload.BulkOrderFailed
+			result.Results[i].Err = err
+			result.Failed++
+			continue
+		}
+
+		orders = append(orders, newOrder(quote, now))
+		indexes = append(indexes, i)
+	}
+
+	if mode == payload.BulkModePartial {
+		for j, order := range orders {
+			saved, err := s.ordersRepository.SaveOrder(order)
+			if err != nil {
+				result.Results[indexes[j]].Status = payload.BulkOrderFailed
+				result.Results[indexes[j]].Err = err
+				result.Failed++
+				continue
+			}
+
+			result.Results[indexes[j]].Status = payload.BulkOrderCreated
+			result.Results[indexes[j]].Order = &saved
+			result.Created++
+		}
+
+		return result, nil
+	}
+
+	if result.Failed > 0 {
+		for _, i := range indexes {
+			result.Results[i].Status = payload.BulkOrderSkipped
+		}
+
+		return result, nil
+	}
+
+	saved, err := s.ordersRepository.SaveOrders(orders)
+	if err != nil {
+		return payload.BulkOrdersResult{}, err
+	}
+
+	for j := range saved {
+		result.Results[indexes[j]].Status = payload.BulkOrderCreated
+		result.Results[indexes[j]].Order = &saved[j]
+	}
+	result.Created = len(saved)
+
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_CreateOrders(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	ordersRepo := repositories.NewInMemoryOrdersRepository()
+	service := NewOrdersService(
+		ordersRepo,
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	valid := payload.CreateOrder{ItemsCount: 251}
+	invalid := payload.CreateOrder{ItemsCount: 251, Lines: []payload.OrderLine{{ItemsCount: 500}}}
+
+	testCases := []struct {
+		name             string
+		input            payload.CreateOrders
+		expectedStatuses []string
+		expectedCreated  int
+		expectedError    error
+	}{
+		{
+			name:             "Atomic batch",
+			input:            payload.CreateOrders{Orders: []payload.CreateOrder{valid, {ItemsCount: 12001}, valid}},
+			expectedStatuses: []string{payload.BulkOrderCreated, payload.BulkOrderCreated, payload.BulkOrderCreated},
+			expectedCreated:  3,
+		},
+		{
+			name:             "Atomic batch with a failed order",
+			input:            payload.CreateOrders{Orders: []payload.CreateOrder{valid, invalid, valid}, Mode: payload.BulkModeAtomic},
+			expectedStatuses: []string{payload.BulkOrderSkipped, payload.BulkOrderFailed, payload.BulkOrderSkipped},
+		},
+		{
+			name:             "Partial batch with a failed order",
+			input:            payload.CreateOrders{Orders: []payload.CreateOrder{valid, invalid, valid}, Mode: payload.BulkModePartial},
+			expectedStatuses: []string{payload.BulkOrderCreated, payload.BulkOrderFailed, payload.BulkOrderCreated},
+			expectedCreated:  2,
+		},
+		{
+			name:          "Unknown mode",
+			input:         payload.CreateOrders{Orders: []payload.CreateOrder{valid}, Mode: "eventually"},
+			expectedError: payload.ErrInvalidBulkMode,
+		},
+		{
+			name:          "No orders",
+			input:         payload.CreateOrders{},
+			expectedError: payload.ErrEmptyBulk,
+		},
+		{
+			name:          "Too many orders",
+			input:         payload.CreateOrders{Orders: make([]payload.CreateOrder, maxBulkOrders+1)},
+			expectedError: payload.ErrTooManyBulkOrders,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ordersRepo.Clear()
+
+			result, err := service.CreateOrders(tc.input)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if result.Created != tc.expectedCreated || ordersRepo.Count() != tc.expectedCreated {
+				t.Errorf("expected %d orders created, got %d reported and %d saved", tc.expectedCreated, result.Created, ordersRepo.Count())
+			}
+			if result.Failed != countStatus(result, payload.BulkOrderFailed) {
+				t.Errorf("expected the failed orders to be counted, got %d", result.Failed)
+			}
+
+			for i, status := range tc.expectedStatuses {
+				item := result.Results[i]
+				if item.Index != i || item.Status != status {
+					t.Errorf("expected order %d to be %s, got %d %s", i, status, item.Index, item.Status)
+				}
+				if (status == payload.BulkOrderCreated) != (item.Order != nil) {
+					t.Errorf("expected only created orders to be returned, got %+v", item)
+				}
+				if status == payload.BulkOrderFailed && !errors.Is(item.Err, payload.ErrAmbiguousOrderLines) {
+					t.Errorf("expected ErrAmbiguousOrderLines, got %v", item.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdersService_CreateOrdersSharesStock(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepositoryWithStock(packSizesRepo),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	for _, ps := range packSizes {
+		if ps.Size == 5000 {
+			stock := 2
+			if _, err := packSizesService.SetPackSizeStock(ps.ID, &stock); err != nil {
+				t.Fatalf("failed to set stock: %v", err)
+			}
+		}
+	}
+
+	// The first order takes both 5000 packs, so the second can't have them
+	result, err := service.CreateOrders(payload.CreateOrders{Orders: []payload.CreateOrder{{ItemsCount: 10000}, {ItemsCount: 5000}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"2x5000", "2x2000, 1x1000"}
+	for i, packSetup := range expected {
+		if order := result.Results[i].Order; order == nil || order.PackSetup != packSetup {
+			t.Errorf("expected order %d packed as %q, got %+v", i, packSetup, result.Results[i])
+		}
+	}
+}
+
+func countStatus(result payload.BulkOrdersResult, status string) int {
+	count := 0
+	for _, item := range result.Results {
+		if item.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+type CatalogChangesRepository interface {
+	ListenCatalogChanges(ctx context.Context, handle func(change models.CatalogChange)) error
+}
+
+// CatalogSyncService keeps the in-memory state of every API replica in step
+// with pack size changes made on any of them. Each replica has an origin of
+// its own, so it skips the changes it published itself, which its own
+// PackSizesService listeners already handled.
+type CatalogSyncService struct {
+	repo   CatalogChangesRepository
+	origin uuid.UUID
+}
+
+func NewCatalogSyncService(repo CatalogChangesRepository) *CatalogSyncService {
+	return &CatalogSyncService{
+		repo:   repo,
+		origin: uuid.New(),
+	}
+}
+
+// Change returns the change of the product's pack sizes, made by this
+// replica, to let the other replicas know about. It fits
+// PackSizesService.AnnounceChanges, which sends it along with the change.
+func (s *CatalogSyncService) Change(productID uuid.UUID) models.CatalogChange {
+	return models.CatalogChange{ProductID: productID, Origin: s.origin}
+}
+
+// Run calls the listeners with the product of every change published by
+// the other replicas, or uuid.Nil when any product may have changed, until
+// ctx is done.
+func (s *CatalogSyncService) Run(ctx context.Context, listeners ...func(productID uuid.UUID)) error {
+	return s.repo.ListenCatalogChanges(ctx, func(change models.CatalogChange) {
+		if change.Origin == s.origin {
+			return
+		}
+
+		for _, listener := range listeners {
+			listener(change.ProductID)
+		}
+	})
+}
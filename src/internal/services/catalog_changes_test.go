@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestCatalogSyncService_InvalidatesOtherReplicas(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	changes := repositories.NewInMemoryCatalogChangesRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newOrdersService := func() *OrdersService {
+		return NewOrdersService(
+			repositories.NewInMemoryOrdersRepository(),
+			packSizesRepo,
+			repositories.NewInMemoryProductsRepository(),
+			config.OrdersConfig{},
+		)
+	}
+
+	// Replica A makes the change, replica B only listens
+	ordersA, ordersB := newOrdersService(), newOrdersService()
+	packSizesA := NewPackSizesService(packSizesRepo)
+	syncA, syncB := NewCatalogSyncService(changes), NewCatalogSyncService(changes)
+
+	packSizesA.OnChange(ordersA.InvalidateSolverCache)
+	packSizesA.AnnounceChanges(syncA.Change)
+	packSizesRepo.SendNotificationsTo(changes)
+
+	var notifiedA atomic.Int32
+	go func() {
+		_ = syncA.Run(ctx, func(uuid.UUID) { notifiedA.Add(1) })
+	}()
+
+	var notifiedB atomic.Value
+	go func() {
+		_ = syncB.Run(ctx, ordersB.InvalidateSolverCache, func(productID uuid.UUID) { notifiedB.Store(productID) })
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for changes.Listeners() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("replicas didn't start listening")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, orders := range []*OrdersService{ordersA, ordersB} {
+		if _, err := orders.QuoteOrder(payload.CreateOrder{ItemsCount: 12001}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	_, err := packSizesA.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 12001})
+	if err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+
+	if ordersA.solverTables.len() != 0 {
+		t.Errorf("expected replica A's cache to be invalidated, got %d tables", ordersA.solverTables.len())
+	}
+	if ordersB.solverTables.len() != 0 {
+		t.Errorf("expected replica B's cache to be invalidated, got %d tables", ordersB.solverTables.len())
+	}
+	if productID, _ := notifiedB.Load().(uuid.UUID); productID != models.DefaultProductID {
+		t.Errorf("expected replica B to be notified of product %v, got %v", models.DefaultProductID, productID)
+	}
+	if notifiedA.Load() != 0 {
+		t.Errorf("expected replica A to skip its own change, got notified %d times", notifiedA.Load())
+	}
+
+	quote, err := ordersB.QuoteOrder(payload.CreateOrder{ItemsCount: 12001})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.PackSetup != "1x12001" {
+		t.Errorf("expected replica B to use the new pack size, got %q", quote.PackSetup)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// maxAlternatives caps the alternatives returned with an explanation.
+const maxAlternatives = 5
+
+// strategyReasons describes what every strategy optimizes for.
+var strategyReasons = map[string]string{
+	StrategyFewestItems: "ships the fewest items possible, in the fewest packs that make them",
+	StrategyFewestPacks: "uses the fewest packs possible, shipping the fewest items they can make",
+	StrategyGreedy:      "fills the order with the largest packs first",
+	StrategyCheapest:    "costs the least, shipping the fewest items and packs for that cost",
+}
+
+// explainPacking compares the packing chosen for a line with the ones it was
+// weighed against, taken from the same packing space the solver picks from:
+// the same items packed without the largest pack size the chosen packing
+// uses, then every amount of items that takes fewer packs than all the
+// smaller amounts, from the fewest items shipped to the fewest packs used.
+func (s *OrdersService) explainPacking(
+	itemsCount int,
+	available []models.PackSize,
+	catalog []models.PackSize,
+	strategy PackingStrategy,
+	chosen PackCombinationResult,
+) (*models.PackingExplanation, error) {
+	explanation := &models.PackingExplanation{
+		Chosen: packingCandidate(itemsCount, chosen, catalog,
+			fmt.Sprintf("chosen by the %s strategy, which %s", strategy.Name(), strategyReasons[strategy.Name()])),
+		Alternatives: []models.PackingCandidate{},
+	}
+
+	if itemsCount <= 0 {
+		return explanation, nil
+	}
+
+	sizes, stock := splitPackSizes(available)
+	tables := s.solverTables.product(packSizesProduct(available))
+	space, err := stockSpace(itemsCount, sizes, stock, tables, s.config.MaxBoundedItemsCount)
+	if err != nil {
+		return nil, err
+	}
+
+	alternatives := []PackCombinationResult{}
+	if alternative, exists := s.withoutLargestPack(chosen, sizes, stock, tables); exists {
+		alternatives = append(alternatives, alternative)
+	}
+
+	fewestPacks := -1
+	for i, packs := range space.packs {
+		if fewestPacks != -1 && packs >= fewestPacks {
+			continue
+		}
+		fewestPacks = packs
+
+		alternatives = append(alternatives, space.result(i))
+	}
+
+	// Packings that score the same as the chosen one or an earlier
+	// alternative add nothing to the explanation
+	seen := map[[2]int]bool{{chosen.TotalItems, chosen.TotalPacks}: true}
+	for _, alternative := range alternatives {
+		score := [2]int{alternative.TotalItems, alternative.TotalPacks}
+		if seen[score] || len(explanation.Alternatives) == maxAlternatives {
+			continue
+		}
+		seen[score] = true
+
+		explanation.Alternatives = append(explanation.Alternatives,
+			packingCandidate(itemsCount, alternative, catalog, alternativeReason(alternative, chosen)))
+	}
+
+	return explanation, nil
+}
+
+// withoutLargestPack packs the items shipped by the chosen packing again
+// without the largest pack size it uses, which is what answers why an order
+// got one large pack rather than a few smaller ones.
+func (s *OrdersService) withoutLargestPack(
+	chosen PackCombinationResult,
+	sizes []int,
+	stock map[int]int,
+	tables productTables,
+) (PackCombinationResult, bool) {
+	largest := 0
+	for size, count := range chosen.Packs {
+		if count > 0 {
+			largest = max(largest, size)
+		}
+	}
+
+	others := make([]int, 0, len(sizes))
+	for _, size := range sizes {
+		if size != largest {
+			others = append(others, size)
+		}
+	}
+
+	space, err := stockSpace(chosen.TotalItems, others, stock, tables, s.config.MaxBoundedItemsCount)
+	if err != nil || len(space.amounts) == 0 || space.amounts[0] != chosen.TotalItems {
+		return PackCombinationResult{}, false
+	}
+
+	return space.result(0), true
+}
+
+func packingCandidate(itemsCount int, combination PackCombinationResult, catalog []models.PackSize, reason string) models.PackingCandidate {
+	packs := formatPacks(combination.Packs, catalog)
+
+	return models.PackingCandidate{
+		PackSetup:      formatPackSetup(packs),
+		Packs:          packs,
+		ShippedItems:   combination.TotalItems,
+		Overshoot:      max(combination.TotalItems-itemsCount, 0),
+		TotalPacks:     combination.TotalPacks,
+		TotalCostCents: packsTotal(packs, func(pack models.OrderPack) int { return pack.UnitCostCents }),
+		Reason:         reason,
+	}
+}
+
+// alternativeReason says how an alternative compares to the chosen packing.
+func alternativeReason(alternative, chosen PackCombinationResult) string {
+	items := alternative.TotalItems - chosen.TotalItems
+	packs := alternative.TotalPacks - chosen.TotalPacks
+
+	switch {
+	case items == 0 && packs > 0:
+		return fmt.Sprintf("ships the same %s but takes %s more", countOf(alternative.TotalItems, "item"), countOf(packs, "pack"))
+	case items == 0 && packs < 0:
+		return fmt.Sprintf("ships the same %s with %s fewer", countOf(alternative.TotalItems, "item"), countOf(-packs, "pack"))
+	case items > 0 && packs < 0:
+		return fmt.Sprintf("takes %s fewer but ships %s more", countOf(-packs, "pack"), countOf(items, "item"))
+	case items < 0 && packs > 0:
+		return fmt.Sprintf("ships %s fewer but takes %s more", countOf(-items, "item"), countOf(packs, "pack"))
+	default:
+		return fmt.Sprintf("ships %s in %s", countOf(alternative.TotalItems, "item"), countOf(alternative.TotalPacks, "pack"))
+	}
+}
+
+func countOf(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_QuoteOrderExplain(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	testCases := []struct {
+		name                 string
+		itemsCount           int
+		strategy             string
+		expectedChosen       string
+		expectedAlternatives []string
+		expectedReason       string
+	}{
+		{
+			name:                 "One large pack over a few smaller ones",
+			itemsCount:           251,
+			expectedChosen:       "1x500",
+			expectedAlternatives: []string{"2x250"},
+			expectedReason:       "ships the same 500 items but takes 1 pack more",
+		},
+		{
+			name:                 "Fewer packs with more overshoot",
+			itemsCount:           12001,
+			expectedChosen:       "2x5000, 1x2000, 1x250",
+			expectedAlternatives: []string{"6x2000, 1x250", "3x5000"},
+			expectedReason:       "ships the same 12250 items but takes 3 packs more",
+		},
+		{
+			name:                 "Fewer items with more packs",
+			itemsCount:           12001,
+			strategy:             StrategyFewestPacks,
+			expectedChosen:       "3x5000",
+			expectedAlternatives: []string{"7x2000, 1x1000", "2x5000, 1x2000, 1x250"},
+			expectedReason:       "ships the same 15000 items but takes 5 packs more",
+		},
+		{
+			name:                 "Exact fit",
+			itemsCount:           250,
+			expectedChosen:       "1x250",
+			expectedAlternatives: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: tc.itemsCount, Strategy: tc.strategy, Explain: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			explanation := quote.Explanation
+			if explanation == nil || quote.Lines[0].Explanation != explanation {
+				t.Fatalf("expected the line's explanation at the top level, got %v", explanation)
+			}
+
+			if explanation.Chosen.PackSetup != quote.PackSetup || explanation.Chosen.PackSetup != tc.expectedChosen {
+				t.Errorf("expected chosen pack setup %q, got %q", tc.expectedChosen, explanation.Chosen.PackSetup)
+			}
+
+			if len(explanation.Alternatives) != len(tc.expectedAlternatives) {
+				t.Fatalf("expected %d alternatives, got %+v", len(tc.expectedAlternatives), explanation.Alternatives)
+			}
+			for i, alternative := range explanation.Alternatives {
+				if alternative.PackSetup != tc.expectedAlternatives[i] {
+					t.Errorf("expected alternative %d to be %q, got %q", i, tc.expectedAlternatives[i], alternative.PackSetup)
+				}
+				if alternative.Overshoot != alternative.ShippedItems-tc.itemsCount {
+					t.Errorf("expected overshoot %d, got %d", alternative.ShippedItems-tc.itemsCount, alternative.Overshoot)
+				}
+			}
+
+			if tc.expectedReason != "" && explanation.Alternatives[0].Reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, explanation.Alternatives[0].Reason)
+			}
+		})
+	}
+
+	// Explanations are only returned on request, but survive saving the order
+	quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 251})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.Explanation != nil || quote.Lines[0].Explanation != nil {
+		t.Errorf("expected no explanation, got %v", quote.Explanation)
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251, Explain: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Explanation == nil || order.Lines[0].Explanation == nil {
+		t.Errorf("expected the created order to be explained")
+	}
+}
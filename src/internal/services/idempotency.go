@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+const (
+	// uniqueViolation is the PostgreSQL error code raised when a row
+	// duplicates the key of another.
+	uniqueViolation = "23505"
+
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+)
+
+// createIdempotentOrder creates the order once per idempotency key. Retries
+// of the same request get the response of the first one back, marked as
+// replayed, until the key expires, even if the order changed since. A
+// different request with the key is refused with
+// payload.ErrIdempotencyKeyReused. Two requests racing with a new key are
+// settled by the repository, which only records the key once.
+func (s *OrdersService) createIdempotentOrder(input payload.CreateOrder) (models.Order, error) {
+	if len(input.IdempotencyKey) > maxIdempotencyKeyLength {
+		return models.Order{}, payload.ErrInvalidIdempotencyKey
+	}
+
+	requestHash, err := hashOrderRequest(input)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// Timestamps are kept to the microsecond like PostgreSQL does, so the
+	// response replayed is the one returned now
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if order, found, err := s.replayOrder(input.IdempotencyKey, requestHash, now); found || err != nil {
+		return order, err
+	}
+
+	quote, err := s.QuoteOrder(input)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	ttl := s.config.IdempotencyKeyTTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	order := newOrder(quote, now)
+	response := withExplanations(order, quote)
+	saved, err := s.ordersRepository.SaveOrderWithIdempotencyKey(order, models.IdempotencyKey{
+		Key:         input.IdempotencyKey,
+		RequestHash: requestHash,
+		OrderID:     order.ID,
+		Response:    &response,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			// Another request with the key got there first
+			if order, found, err := s.replayOrder(input.IdempotencyKey, requestHash, now); found || err != nil {
+				return order, err
+			}
+		}
+
+		return models.Order{}, err
+	}
+
+	return withExplanations(saved, quote), nil
+}
+
+// replayOrder returns the response of the request that created an order
+// with the key, if the key's still in use. Keys recorded without a response
+// get the order as it is now instead.
+func (s *OrdersService) replayOrder(key, requestHash string, now time.Time) (models.Order, bool, error) {
+	existing, err := s.ordersRepository.FetchIdempotencyKey(key, now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.Order{}, false, nil
+		}
+
+		return models.Order{}, false, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return models.Order{}, true, payload.ErrIdempotencyKeyReused
+	}
+
+	if existing.Response == nil {
+		order, err := s.GetOrder(existing.OrderID)
+		order.Replayed = err == nil
+		return order, true, err
+	}
+
+	order := *existing.Response
+	order.Replayed = true
+	return order, true, nil
+}
+
+// hashOrderRequest identifies the body of an order creation request. The
+// request is hashed as decoded, so retries that only format the JSON
+// differently still match.
+func hashOrderRequest(input payload.CreateOrder) (string, error) {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
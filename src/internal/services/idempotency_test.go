@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_CreateOrderIdempotencyKey(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	ordersRepo := repositories.NewInMemoryOrdersRepository()
+	service := NewOrdersService(
+		ordersRepo,
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	first, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251, IdempotencyKey: "checkout-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Retries get the same order, even when asking for an explanation
+	retry, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251, IdempotencyKey: "checkout-1", Explain: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retry.ID != first.ID || ordersRepo.Count() != 1 {
+		t.Errorf("expected the retry to return order %s without creating another, got %s and %d orders", first.ID, retry.ID, ordersRepo.Count())
+	}
+
+	testCases := []struct {
+		name          string
+		input         payload.CreateOrder
+		expectedError error
+		expectedNew   bool
+	}{
+		{
+			name:          "Same key with another body",
+			input:         payload.CreateOrder{ItemsCount: 500, IdempotencyKey: "checkout-1"},
+			expectedError: payload.ErrIdempotencyKeyReused,
+		},
+		{
+			name:        "Another key with the same body",
+			input:       payload.CreateOrder{ItemsCount: 251, IdempotencyKey: "checkout-2"},
+			expectedNew: true,
+		},
+		{
+			name:        "No key",
+			input:       payload.CreateOrder{ItemsCount: 251},
+			expectedNew: true,
+		},
+		{
+			name:          "Key too long",
+			input:         payload.CreateOrder{ItemsCount: 251, IdempotencyKey: strings.Repeat("k", 256)},
+			expectedError: payload.ErrInvalidIdempotencyKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := service.CreateOrder(tc.input)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedNew && order.ID == first.ID {
+				t.Errorf("expected a new order, got order %s again", first.ID)
+			}
+		})
+	}
+}
+
+func TestOrdersService_CreateOrderExpiredIdempotencyKey(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{IdempotencyKeyTTL: time.Millisecond},
+	)
+
+	first, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251, IdempotencyKey: "checkout-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	// Once expired, the key can be used again, even for another request
+	second, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 500, IdempotencyKey: "checkout-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("expected a new order once the key expired, got order %s again", first.ID)
+	}
+}
+
+func TestOrdersService_CreateOrderReplayAfterAmendment(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	input := payload.CreateOrder{ItemsCount: 251, IdempotencyKey: "checkout-1", Explain: true}
+	first, err := service.CreateOrder(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Replayed {
+		t.Errorf("expected the first response not to be replayed")
+	}
+
+	if _, err := service.AmendOrder(first.ID, payload.AmendOrder{ItemsCount: 10000, ChangedBy: "support"}); err != nil {
+		t.Fatalf("failed to amend order: %v", err)
+	}
+
+	// The retry gets the response of the first request, not the amended order
+	retry, err := service.CreateOrder(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !retry.Replayed {
+		t.Errorf("expected the retry to be replayed")
+	}
+
+	retry.Replayed = false
+	if !reflect.DeepEqual(retry, first) {
+		t.Errorf("expected the first response %+v replayed, got %+v", first, retry)
+	}
+}
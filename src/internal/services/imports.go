@@ -0,0 +1,308 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// maxImportRows caps the rows of an imported CSV.
+const maxImportRows = 10000
+
+// importRow is a row of an imported CSV with its values keyed by column.
+// Columns missing from the file read as empty.
+type importRow struct {
+	line   int
+	values map[string]string
+}
+
+// readImportRows reads a CSV with a header row naming the columns, in any
+// order. Unknown, repeated or missing required columns and malformed rows
+// are reported as row errors, in which case the rows read so far are
+// returned with them.
+func readImportRows(r io.Reader, columns []string, required []string) ([]importRow, []payload.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, payload.ErrEmptyImport
+		}
+
+		return nil, []payload.ImportRowError{csvError(err)}, nil
+	}
+
+	var rowErrors []payload.ImportRowError
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		header[i] = column
+
+		switch {
+		case !slices.Contains(columns, column):
+			rowErrors = append(rowErrors, payload.ImportRowError{
+				Row: 1, Column: column, Message: fmt.Sprintf("unknown column, expected %s", strings.Join(columns, ", ")),
+			})
+		case slices.Index(header, column) < i:
+			rowErrors = append(rowErrors, payload.ImportRowError{Row: 1, Column: column, Message: "repeated column"})
+		}
+	}
+	for _, column := range required {
+		if !slices.Contains(header, column) {
+			rowErrors = append(rowErrors, payload.ImportRowError{Row: 1, Column: column, Message: "missing column"})
+		}
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors, nil
+	}
+
+	rows := []importRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return rows, []payload.ImportRowError{csvError(err)}, nil
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, nil, payload.ErrTooManyImportRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line, values: make(map[string]string, len(header))}
+		for i, value := range record {
+			row.values[header[i]] = strings.TrimSpace(value)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, payload.ErrEmptyImport
+	}
+
+	return rows, nil, nil
+}
+
+func csvError(err error) payload.ImportRowError {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return payload.ImportRowError{Row: parseErr.Line, Message: parseErr.Err.Error()}
+	}
+
+	return payload.ImportRowError{Message: err.Error()}
+}
+
+// optionalInt reads an integer column, nil when the column is empty. A
+// value that isn't an integer is added to rowErrors.
+func (row importRow) optionalInt(column string, rowErrors *[]payload.ImportRowError) *int {
+	raw := row.values[column]
+	if raw == "" {
+		return nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		*rowErrors = append(*rowErrors, payload.ImportRowError{Row: row.line, Column: column, Message: "expected an integer"})
+		return nil
+	}
+
+	return &value
+}
+
+// int reads an integer column, zero when the column is empty.
+func (row importRow) int(column string, rowErrors *[]payload.ImportRowError) int {
+	if value := row.optionalInt(column, rowErrors); value != nil {
+		return *value
+	}
+
+	return 0
+}
+
+var (
+	packSizeImportColumns = []string{"size", "stock", "unit_cost_cents", "weight_grams", "length_mm", "width_mm", "height_mm"}
+	orderImportColumns    = []string{"order", "product_id", "items_count", "strategy"}
+
+	// packSizeErrorColumns is the column each pack size rule checks.
+	packSizeErrorColumns = map[error]string{
+		payload.ErrInvalidPackSize: "size",
+		payload.ErrInvalidStock:    "stock",
+		payload.ErrInvalidCost:     "unit_cost_cents",
+		payload.ErrInvalidWeight:   "weight_grams",
+		payload.ErrInvalidLength:   "length_mm",
+		payload.ErrInvalidWidth:    "width_mm",
+		payload.ErrInvalidHeight:   "height_mm",
+	}
+)
+
+// ImportPackSizes creates a pack size of the product for every row of the
+// CSV, with the same rules as CreatePackSize. Sizes the product already has
+// or that appear twice are refused. Nothing is imported when any row has
+// errors, and otherwise the pack sizes are created together with a single
+// catalog version that adds them all.
+func (s *PackSizesService) ImportPackSizes(productID uuid.UUID, r io.Reader) (payload.PackSizesImport, error) {
+	rows, rowErrors, err := readImportRows(r, packSizeImportColumns, []string{"size"})
+	if err != nil {
+		return payload.PackSizesImport{}, err
+	}
+
+	// The sizes the product already has are checked once it's locked, so
+	// a size created meanwhile is reported like any other
+	saved, err := s.changePackSizes(productID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		// The row each size was first seen on, 0 for the existing ones
+		seen := make(map[int]int, len(state.PackSizes)+len(rows))
+		for _, ps := range state.PackSizes {
+			seen[ps.Size] = 0
+		}
+
+		packSizes := make([]models.PackSize, 0, len(rows))
+		for _, row := range rows {
+			before := len(rowErrors)
+			packSize := models.PackSize{
+				ID:            uuid.New(),
+				ProductID:     productID,
+				Active:        true,
+				Size:          row.int("size", &rowErrors),
+				Stock:         row.optionalInt("stock", &rowErrors),
+				UnitCostCents: row.int("unit_cost_cents", &rowErrors),
+				WeightGrams:   row.int("weight_grams", &rowErrors),
+				LengthMm:      row.int("length_mm", &rowErrors),
+				WidthMm:       row.int("width_mm", &rowErrors),
+				HeightMm:      row.int("height_mm", &rowErrors),
+			}
+			if len(rowErrors) > before {
+				continue
+			}
+
+			if err := validatePackSize(packSize); err != nil {
+				rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: packSizeErrorColumns[err], Message: err.Error()})
+				continue
+			}
+
+			if line, exists := seen[packSize.Size]; exists {
+				message := "the product already has this pack size"
+				if line > 0 {
+					message = fmt.Sprintf("repeats the pack size of row %d", line)
+				}
+				rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: "size", Message: message})
+				continue
+			}
+			seen[packSize.Size] = row.line
+
+			packSizes = append(packSizes, packSize)
+		}
+
+		if len(rowErrors) > 0 {
+			return models.PackSizeChanges{}, nil
+		}
+
+		return publishChanges(productID, state, models.PackSizeChanges{Created: packSizes}), nil
+	})
+	if err != nil {
+		return payload.PackSizesImport{}, err
+	}
+
+	if len(rowErrors) > 0 {
+		return payload.PackSizesImport{PackSizes: []models.PackSize{}, Errors: rowErrors}, nil
+	}
+
+	return payload.PackSizesImport{
+		Imported:  len(saved.Created),
+		PackSizes: saved.Created,
+		Errors:    []payload.ImportRowError{},
+	}, nil
+}
+
+// ImportOrders creates an order for every row of the CSV, or for every
+// group of rows sharing the same order column, which are the lines of a
+// single order. The orders are created as an atomic bulk creation, so
+// nothing is imported when any row has errors. Errors of an order that
+// can't be packed are reported on its first row.
+func (s *OrdersService) ImportOrders(r io.Reader) (payload.OrdersImport, error) {
+	rows, rowErrors, err := readImportRows(r, orderImportColumns, []string{"items_count"})
+	if err != nil {
+		return payload.OrdersImport{}, err
+	}
+
+	orders := []payload.CreateOrder{}
+	firstRows := []int{}
+	byReference := make(map[string]int)
+	for _, row := range rows {
+		var line payload.OrderLine
+		if itemsCount := row.optionalInt("items_count", &rowErrors); itemsCount != nil {
+			line.ItemsCount = *itemsCount
+			if line.ItemsCount <= 0 {
+				rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: "items_count", Message: payload.ErrInvalidItemsCount.Error()})
+			}
+		} else if row.values["items_count"] == "" {
+			rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: "items_count", Message: "items count is required"})
+		}
+
+		if raw := row.values["product_id"]; raw != "" {
+			productID, err := uuid.Parse(raw)
+			if err != nil {
+				rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: "product_id", Message: "invalid product ID"})
+			}
+			line.ProductID = productID
+		}
+
+		strategy := row.values["strategy"]
+		if _, err := packingStrategy(s.strategies, strategy, s.config.DefaultStrategy); err != nil {
+			rowErrors = append(rowErrors, payload.ImportRowError{Row: row.line, Column: "strategy", Message: err.Error()})
+		}
+
+		reference := row.values["order"]
+		if i, exists := byReference[reference]; exists && reference != "" {
+			if orders[i].Strategy != strategy {
+				rowErrors = append(rowErrors, payload.ImportRowError{
+					Row: row.line, Column: "strategy", Message: fmt.Sprintf("differs from the strategy of the order's first row, row %d", firstRows[i]),
+				})
+			}
+
+			orders[i].Lines = append(orders[i].Lines, line)
+			continue
+		}
+
+		byReference[reference] = len(orders)
+		orders = append(orders, payload.CreateOrder{Lines: []payload.OrderLine{line}, Strategy: strategy})
+		firstRows = append(firstRows, row.line)
+	}
+
+	if len(rowErrors) > 0 {
+		return payload.OrdersImport{Orders: []models.Order{}, Errors: rowErrors}, nil
+	}
+
+	result, err := s.CreateOrders(payload.CreateOrders{Orders: orders, Mode: payload.BulkModeAtomic})
+	if err != nil {
+		return payload.OrdersImport{}, err
+	}
+
+	created := make([]models.Order, 0, result.Created)
+	for i, item := range result.Results {
+		switch item.Status {
+		case payload.BulkOrderCreated:
+			created = append(created, *item.Order)
+		case payload.BulkOrderFailed:
+			rowErrors = append(rowErrors, payload.ImportRowError{Row: firstRows[i], Err: item.Err})
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return payload.OrdersImport{Orders: []models.Order{}, Errors: rowErrors}, nil
+	}
+
+	return payload.OrdersImport{
+		Imported: len(created),
+		Orders:   created,
+		Errors:   []payload.ImportRowError{},
+	}, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestPackSizesService_ImportPackSizes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		csv            string
+		expectedSizes  []int
+		expectedErrors []payload.ImportRowError
+		expectedError  error
+	}{
+		{
+			name:          "Every column",
+			csv:           "size,stock,unit_cost_cents,weight_grams,length_mm,width_mm,height_mm\n250,,10,100,10,10,10\n500,3,20,200,20,20,20\n",
+			expectedSizes: []int{250, 500, 1000},
+		},
+		{
+			name:          "Columns in any order",
+			csv:           " Unit_Cost_Cents , size\n10,2000\n",
+			expectedSizes: []int{1000, 2000},
+		},
+		{
+			name: "Invalid rows",
+			csv:  "size,stock,weight_grams,height_mm\n250,,,\n0,,,\nabc,,,\n250,,,\n500,-1,,\n1000,,,\n750,,-5,\n1500,,,-1\n",
+			expectedErrors: []payload.ImportRowError{
+				{Row: 3, Column: "size", Message: payload.ErrInvalidPackSize.Error()},
+				{Row: 4, Column: "size", Message: "expected an integer"},
+				{Row: 5, Column: "size", Message: "repeats the pack size of row 2"},
+				{Row: 6, Column: "stock", Message: payload.ErrInvalidStock.Error()},
+				{Row: 7, Column: "size", Message: "the product already has this pack size"},
+				{Row: 8, Column: "weight_grams", Message: payload.ErrInvalidWeight.Error()},
+				{Row: 9, Column: "height_mm", Message: payload.ErrInvalidHeight.Error()},
+			},
+		},
+		{
+			name: "Invalid header",
+			csv:  "stock,colour,stock\n1,red,2\n",
+			expectedErrors: []payload.ImportRowError{
+				{Row: 1, Column: "colour", Message: "unknown column, expected size, stock, unit_cost_cents, weight_grams, length_mm, width_mm, height_mm"},
+				{Row: 1, Column: "stock", Message: "repeated column"},
+				{Row: 1, Column: "size", Message: "missing column"},
+			},
+		},
+		{
+			name: "Malformed CSV",
+			csv:  "size,stock\n250,1\n500\n",
+			expectedErrors: []payload.ImportRowError{
+				{Row: 3, Message: "wrong number of fields"},
+			},
+		},
+		{
+			name:          "Header only",
+			csv:           "size\n",
+			expectedError: payload.ErrEmptyImport,
+		},
+		{
+			name:          "Empty",
+			csv:           "",
+			expectedError: payload.ErrEmptyImport,
+		},
+		{
+			name:          "Too many rows",
+			csv:           "size\n" + strings.Repeat("1\n", maxImportRows+1),
+			expectedError: payload.ErrTooManyImportRows,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := repositories.NewInMemoryPackSizesRepository()
+			service := NewPackSizesService(repo)
+
+			if _, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 1000}); err != nil {
+				t.Fatalf("failed to create pack size: %v", err)
+			}
+
+			changes := 0
+			service.OnChange(func(productID uuid.UUID) { changes++ })
+
+			result, err := service.ImportPackSizes(models.DefaultProductID, strings.NewReader(tc.csv))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(tc.expectedErrors) > 0 {
+				if !reflect.DeepEqual(result.Errors, tc.expectedErrors) {
+					t.Errorf("expected errors %+v, got %+v", tc.expectedErrors, result.Errors)
+				}
+				if result.Imported != 0 || changes != 0 {
+					t.Errorf("expected nothing to be imported, got %d pack sizes and %d changes", result.Imported, changes)
+				}
+
+				packSizes, _ := repo.GetAllPackSizes(models.DefaultProductID.String())
+				if len(packSizes) != 1 {
+					t.Errorf("expected only the existing pack size, got %d", len(packSizes))
+				}
+				return
+			}
+
+			if len(result.Errors) != 0 || result.Imported != len(tc.expectedSizes)-1 || changes != 1 {
+				t.Fatalf("expected %d pack sizes imported at once, got %+v and %d changes", len(tc.expectedSizes)-1, result, changes)
+			}
+
+			// A single catalog version adds all of them
+			catalog, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sizes := make([]int, len(catalog))
+			for i, ps := range catalog {
+				sizes[i] = ps.Size
+			}
+			if !reflect.DeepEqual(sizes, tc.expectedSizes) {
+				t.Errorf("expected catalog sizes %v, got %v", tc.expectedSizes, sizes)
+			}
+		})
+	}
+}
+
+func TestOrdersService_ImportOrders(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	ordersRepo := repositories.NewInMemoryOrdersRepository()
+	service := NewOrdersService(
+		ordersRepo,
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	unknownProduct := uuid.New()
+
+	testCases := []struct {
+		name               string
+		csv                string
+		expectedPackSetups []string
+		expectedErrors     []payload.ImportRowError
+	}{
+		{
+			name:               "Single and multi line orders",
+			csv:                "order,items_count,product_id,strategy\n,251,,\nA,12001,,fewest-packs\n,500,,\nA,1,,fewest-packs\n",
+			expectedPackSetups: []string{"1x500", "", "1x500"},
+		},
+		{
+			name: "Invalid rows",
+			csv:  "order,items_count,product_id,strategy\n,0,,\n,many,,\n,,,\n,1,not-an-id,\n,1,,biggest\nB,1,,greedy\nB,1,,\n",
+			expectedErrors: []payload.ImportRowError{
+				{Row: 2, Column: "items_count", Message: payload.ErrInvalidItemsCount.Error()},
+				{Row: 3, Column: "items_count", Message: "expected an integer"},
+				{Row: 4, Column: "items_count", Message: "items count is required"},
+				{Row: 5, Column: "product_id", Message: "invalid product ID"},
+				{Row: 6, Column: "strategy", Message: payload.ErrUnknownStrategy.Error()},
+				{Row: 8, Column: "strategy", Message: "differs from the strategy of the order's first row, row 7"},
+			},
+		},
+		{
+			name: "Order that can't be packed",
+			csv:  "order,items_count,product_id\n,251,\nC,1,\nC,1," + unknownProduct.String() + "\n",
+			expectedErrors: []payload.ImportRowError{
+				{Row: 3, Err: payload.ErrProductNotFound},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ordersRepo.Clear()
+
+			result, err := service.ImportOrders(strings.NewReader(tc.csv))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(tc.expectedErrors) > 0 {
+				if len(result.Errors) != len(tc.expectedErrors) {
+					t.Fatalf("expected errors %+v, got %+v", tc.expectedErrors, result.Errors)
+				}
+				for i, expected := range tc.expectedErrors {
+					got := result.Errors[i]
+					if got.Row != expected.Row || got.Column != expected.Column || got.Message != expected.Message || !errors.Is(got.Err, expected.Err) {
+						t.Errorf("expected error %+v, got %+v", expected, got)
+					}
+				}
+				if result.Imported != 0 || ordersRepo.Count() != 0 {
+					t.Errorf("expected nothing to be imported, got %d orders", ordersRepo.Count())
+				}
+				return
+			}
+
+			if result.Imported != len(tc.expectedPackSetups) || ordersRepo.Count() != len(tc.expectedPackSetups) {
+				t.Fatalf("expected %d orders imported, got %d", len(tc.expectedPackSetups), result.Imported)
+			}
+			for i, packSetup := range tc.expectedPackSetups {
+				if result.Orders[i].PackSetup != packSetup {
+					t.Errorf("expected order %d packed as %q, got %q", i, packSetup, result.Orders[i].PackSetup)
+				}
+			}
+
+			multiLine := result.Orders[1]
+			if len(multiLine.Lines) != 2 || multiLine.Strategy != StrategyFewestPacks || multiLine.ItemsCount != 12002 {
+				t.Errorf("expected the rows of order A as the lines of one order, got %+v", multiLine)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// AmendOrder packs the order again for its new items count with the current
+// catalog and the order's strategy, keeping the previous breakdown as a
+// revision. The packs the order holds count as in stock for the new
+// breakdown. Shipped and cancelled orders can't be amended.
+func (s *OrdersService) AmendOrder(orderID uuid.UUID, input payload.AmendOrder) (models.Order, error) {
+	if strings.TrimSpace(input.ChangedBy) == "" {
+		return models.Order{}, payload.ErrMissingChangedBy
+	}
+
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if order.Status == models.OrderStatusShipped || order.Status == models.OrderStatusCancelled {
+		return models.Order{}, payload.ErrOrderNotAmendable
+	}
+
+	lines, err := amendedLines(order, input)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	reserved := make(map[uuid.UUID]int)
+	for _, line := range order.Lines {
+		for _, pack := range line.Packs {
+			reserved[pack.PackSizeID] -= pack.Quantity
+		}
+	}
+
+	quote, err := s.quoteOrder(payload.CreateOrder{Lines: lines, Strategy: order.Strategy}, reserved, s.readPacking)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	now := time.Now().UTC()
+	amended := orderFromQuote(order.ID, quote)
+	amended.Status = order.Status
+	amended.CreatedAt = order.CreatedAt
+	amended.UpdatedAt = now
+
+	saved, err := s.ordersRepository.AmendOrder(amended, models.OrderRevision{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		Previous:  order,
+		ChangedBy: input.ChangedBy,
+		CreatedAt: now,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.Order{}, payload.ErrOrderNotFound
+		}
+
+		return models.Order{}, err
+	}
+
+	return saved, nil
+}
+
+// amendedLines returns the new lines of the order. A single items count
+// keeps the product of a single line order.
+func amendedLines(order models.Order, input payload.AmendOrder) ([]payload.OrderLine, error) {
+	if len(input.Lines) > 0 {
+		if input.ItemsCount != 0 {
+			return nil, payload.ErrAmbiguousOrderLines
+		}
+
+		for _, line := range input.Lines {
+			if line.ItemsCount <= 0 {
+				return nil, payload.ErrInvalidItemsCount
+			}
+		}
+
+		return input.Lines, nil
+	}
+
+	if len(order.Lines) > 1 {
+		return nil, payload.ErrAmendLines
+	}
+
+	if input.ItemsCount <= 0 {
+		return nil, payload.ErrInvalidItemsCount
+	}
+
+	line := payload.OrderLine{ItemsCount: input.ItemsCount}
+	if len(order.Lines) == 1 {
+		line.ProductID = order.Lines[0].ProductID
+	} else if order.ProductID != nil {
+		line.ProductID = *order.ProductID
+	}
+
+	return []payload.OrderLine{line}, nil
+}
+
+// CancelOrder moves the order to cancelled, which puts its packs back in
+// stock. Shipped orders can't be cancelled.
+func (s *OrdersService) CancelOrder(orderID uuid.UUID, changedBy string) (models.Order, error) {
+	return s.UpdateOrderStatus(orderID, payload.UpdateOrderStatus{Status: models.OrderStatusCancelled, ChangedBy: changedBy})
+}
+
+// GetOrderRevisions returns the breakdowns the order had before each of its
+// amendments, from the oldest.
+func (s *OrdersService) GetOrderRevisions(orderID uuid.UUID) ([]models.OrderRevision, error) {
+	if _, err := s.GetOrder(orderID); err != nil {
+		return nil, err
+	}
+
+	return s.ordersRepository.GetOrderRevisions(orderID.String())
+}
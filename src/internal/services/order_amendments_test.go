@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_AmendOrder(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepositoryWithStock(packSizesRepo),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	var largest models.PackSize
+	for _, ps := range packSizes {
+		if ps.Size == 5000 {
+			largest = ps
+		}
+	}
+
+	// Only two 5000 packs, both taken by the order
+	stock := 2
+	if _, err := packSizesService.SetPackSizeStock(largest.ID, &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 10000})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	// The order's own packs are available to its new breakdown
+	amended, err := service.AmendOrder(order.ID, payload.AmendOrder{ItemsCount: 10001, ChangedBy: "support"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if amended.ID != order.ID || !amended.CreatedAt.Equal(order.CreatedAt) || amended.Status != models.OrderStatusQuoted {
+		t.Errorf("expected the same order amended, got %+v", amended)
+	}
+	if amended.PackSetup != "2x5000, 1x250" || amended.ItemsCount != 10001 {
+		t.Errorf("expected 10001 items packed as 2x5000, 1x250, got %d as %q", amended.ItemsCount, amended.PackSetup)
+	}
+
+	// Going down frees the 5000 packs
+	amended, err = service.AmendOrder(order.ID, payload.AmendOrder{ItemsCount: 251, ChangedBy: "support"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if amended.PackSetup != "1x500" {
+		t.Errorf("expected pack setup 1x500, got %q", amended.PackSetup)
+	}
+
+	packSizes, err = packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+	for _, ps := range packSizes {
+		if ps.ID == largest.ID && (ps.Stock == nil || *ps.Stock != 2) {
+			t.Errorf("expected the 5000 packs back in stock, got %v", ps.Stock)
+		}
+	}
+
+	revisions, err := service.GetOrderRevisions(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedPrevious := []string{"2x5000", "2x5000, 1x250"}
+	if len(revisions) != len(expectedPrevious) {
+		t.Fatalf("expected %d revisions, got %d", len(expectedPrevious), len(revisions))
+	}
+	for i, revision := range revisions {
+		if revision.Revision != i+1 || revision.Previous.PackSetup != expectedPrevious[i] || revision.ChangedBy != "support" {
+			t.Errorf("expected revision %d of %q by support, got %+v", i+1, expectedPrevious[i], revision)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		input         payload.AmendOrder
+		expectedError error
+	}{
+		{
+			name:          "Missing changed by",
+			input:         payload.AmendOrder{ItemsCount: 500},
+			expectedError: payload.ErrMissingChangedBy,
+		},
+		{
+			name:          "No items",
+			input:         payload.AmendOrder{ChangedBy: "support"},
+			expectedError: payload.ErrInvalidItemsCount,
+		},
+		{
+			name:          "Lines and items count",
+			input:         payload.AmendOrder{ItemsCount: 500, Lines: []payload.OrderLine{{ItemsCount: 500}}, ChangedBy: "support"},
+			expectedError: payload.ErrAmbiguousOrderLines,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := service.AmendOrder(order.ID, tc.input); !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestOrdersService_AmendAndCancelShippedOrder(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	order, err := service.CreateOrder(payload.CreateOrder{Lines: []payload.OrderLine{{ItemsCount: 251}, {ItemsCount: 500}}})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	_, err = service.AmendOrder(order.ID, payload.AmendOrder{ItemsCount: 500, ChangedBy: "support"})
+	if !errors.Is(err, payload.ErrAmendLines) {
+		t.Errorf("expected ErrAmendLines, got %v", err)
+	}
+
+	amended, err := service.AmendOrder(order.ID, payload.AmendOrder{
+		Lines:     []payload.OrderLine{{ItemsCount: 1}, {ItemsCount: 12001}},
+		ChangedBy: "support",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(amended.Lines) != 2 || amended.Lines[1].PackSetup != "2x5000, 1x2000, 1x250" {
+		t.Errorf("expected the second line packed as 2x5000, 1x2000, 1x250, got %+v", amended.Lines)
+	}
+
+	for _, status := range []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPacked, models.OrderStatusShipped} {
+		if _, err := service.UpdateOrderStatus(order.ID, payload.UpdateOrderStatus{Status: status, ChangedBy: "warehouse"}); err != nil {
+			t.Fatalf("failed to move the order to %q: %v", status, err)
+		}
+	}
+
+	_, err = service.AmendOrder(order.ID, payload.AmendOrder{Lines: []payload.OrderLine{{ItemsCount: 1}}, ChangedBy: "support"})
+	if !errors.Is(err, payload.ErrOrderNotAmendable) {
+		t.Errorf("expected ErrOrderNotAmendable, got %v", err)
+	}
+
+	if _, err := service.CancelOrder(order.ID, "support"); !errors.Is(err, payload.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	// Orders that haven't shipped can be cancelled
+	order, err = service.CreateOrder(payload.CreateOrder{ItemsCount: 251})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	cancelled, err := service.CancelOrder(order.ID, "support")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cancelled.Status != models.OrderStatusCancelled {
+		t.Errorf("expected the order to be cancelled, got %q", cancelled.Status)
+	}
+
+	_, err = service.AmendOrder(order.ID, payload.AmendOrder{ItemsCount: 500, ChangedBy: "support"})
+	if !errors.Is(err, payload.ErrOrderNotAmendable) {
+		t.Errorf("expected ErrOrderNotAmendable, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/utils"
+)
+
+// exportBatchSize is how many orders an export reads at a time.
+const exportBatchSize = maxOrdersLimit
+
+var (
+	orderExportColumns = []string{
+		"order_id", "created_at", "updated_at", "status", "strategy", "lines", "items_count", "shipped_items",
+		"overshoot", "total_packs", "pack_setup", "handling_cost_cents", "total_cost_cents", "total_weight_grams",
+		"total_volume_mm3", "shipments",
+	}
+	packExportColumns = []string{
+		"order_id", "created_at", "status", "line_number", "product_id", "pack_size_id", "pack_size", "quantity",
+		"unit_cost_cents", "weight_grams", "volume_mm3",
+	}
+)
+
+// ExportOrders validates the export and returns the function that writes
+// it. The orders are read a batch at a time as the export is written, from
+// the oldest unless sorted otherwise, so exporting doesn't hold every order
+// in memory. Orders created while exporting may or may not be included.
+func (s *OrdersService) ExportOrders(input payload.ExportOrders) (func(w io.Writer) error, error) {
+	if input.Format == "" {
+		input.Format = payload.ExportFormatCSV
+	}
+	if input.Format != payload.ExportFormatCSV && input.Format != payload.ExportFormatXLSX {
+		return nil, payload.ErrInvalidExportFormat
+	}
+
+	if input.Rows == "" {
+		input.Rows = payload.ExportRowsOrders
+	}
+	if input.Rows != payload.ExportRowsOrders && input.Rows != payload.ExportRowsPacks {
+		return nil, payload.ErrInvalidExportRows
+	}
+
+	query := input.Query
+	if query.Sort == "" {
+		query.Sort = payload.OrderSortCreatedAt
+	}
+	if field, _ := query.SortField(); !slices.Contains(payload.OrderSortFields, field) {
+		return nil, payload.ErrInvalidOrderSort
+	}
+	query.Cursor = ""
+	query.After = nil
+	query.Limit = exportBatchSize
+
+	columns, rows := orderExportColumns, orderExportRows
+	if input.Rows == payload.ExportRowsPacks {
+		columns, rows = packExportColumns, packExportRows
+	}
+
+	return func(w io.Writer) error {
+		var sheet utils.SheetWriter
+		if input.Format == payload.ExportFormatXLSX {
+			var err error
+			if sheet, err = utils.NewXLSXSheet(w, "Orders"); err != nil {
+				return err
+			}
+		} else {
+			sheet = utils.NewCSVSheet(w)
+		}
+
+		if err := sheet.WriteRow(anySlice(columns)...); err != nil {
+			return err
+		}
+
+		for {
+			orders, err := s.ordersRepository.ListOrders(query)
+			if err != nil {
+				return err
+			}
+
+			for _, order := range orders {
+				for _, row := range rows(order) {
+					if err := sheet.WriteRow(row...); err != nil {
+						return err
+					}
+				}
+			}
+
+			if len(orders) < query.Limit {
+				break
+			}
+
+			after := orderCursor(orders[len(orders)-1], query.Sort)
+			query.After = &after
+		}
+
+		return sheet.Close()
+	}, nil
+}
+
+func orderExportRows(order models.Order) [][]any {
+	// Orders with several lines have their packing on the lines only
+	packSetup := order.PackSetup
+	if packSetup == "" && len(order.Lines) > 1 {
+		setups := make([]string, len(order.Lines))
+		for i, line := range order.Lines {
+			setups[i] = line.PackSetup
+		}
+		packSetup = strings.Join(setups, "; ")
+	}
+
+	return [][]any{{
+		order.ID.String(),
+		order.CreatedAt,
+		order.UpdatedAt,
+		string(order.Status),
+		order.Strategy,
+		len(order.Lines),
+		order.ItemsCount,
+		order.ShippedItems,
+		order.Overshoot,
+		order.TotalPacks,
+		packSetup,
+		order.HandlingCostCents,
+		order.TotalCostCents,
+		order.TotalWeightGrams,
+		order.TotalVolumeMm3,
+		len(order.Shipments),
+	}}
+}
+
+func packExportRows(order models.Order) [][]any {
+	rows := [][]any{}
+	for _, line := range order.Lines {
+		for _, pack := range line.Packs {
+			rows = append(rows, []any{
+				order.ID.String(),
+				order.CreatedAt,
+				string(order.Status),
+				line.LineNumber,
+				line.ProductID.String(),
+				pack.PackSizeID.String(),
+				pack.PackSize,
+				pack.Quantity,
+				pack.UnitCostCents,
+				pack.WeightGrams,
+				pack.VolumeMm3,
+			})
+		}
+	}
+
+	return rows
+}
+
+func anySlice[T any](values []T) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_ExportOrders(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	// More orders than fit in a batch
+	for range exportBatchSize {
+		if _, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251}); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+	}
+	multiLine, err := service.CreateOrder(payload.CreateOrder{Lines: []payload.OrderLine{{ItemsCount: 12001}, {ItemsCount: 1}}})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	minItemsCount := 1000
+	testCases := []struct {
+		name            string
+		input           payload.ExportOrders
+		expectedRows    int
+		expectedColumns []string
+		expectedError   error
+	}{
+		{
+			name:            "A row per order",
+			input:           payload.ExportOrders{},
+			expectedRows:    exportBatchSize + 1,
+			expectedColumns: orderExportColumns,
+		},
+		{
+			name:            "A row per pack",
+			input:           payload.ExportOrders{Rows: payload.ExportRowsPacks},
+			expectedRows:    exportBatchSize + 4,
+			expectedColumns: packExportColumns,
+		},
+		{
+			name:            "Filtered",
+			input:           payload.ExportOrders{Query: payload.ListOrders{MinItemsCount: &minItemsCount}},
+			expectedRows:    1,
+			expectedColumns: orderExportColumns,
+		},
+		{
+			name:          "Unknown format",
+			input:         payload.ExportOrders{Format: "pdf"},
+			expectedError: payload.ErrInvalidExportFormat,
+		},
+		{
+			name:          "Unknown rows",
+			input:         payload.ExportOrders{Rows: "lines"},
+			expectedError: payload.ErrInvalidExportRows,
+		},
+		{
+			name:          "Unknown sort",
+			input:         payload.ExportOrders{Query: payload.ListOrders{Sort: "id"}},
+			expectedError: payload.ErrInvalidOrderSort,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			write, err := service.ExportOrders(tc.input)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			var buf bytes.Buffer
+			if err := write(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read the CSV: %v", err)
+			}
+			if len(records) != tc.expectedRows+1 {
+				t.Fatalf("expected %d rows and the header, got %d", tc.expectedRows, len(records))
+			}
+			for i, column := range tc.expectedColumns {
+				if records[0][i] != column {
+					t.Errorf("expected column %d to be %q, got %q", i, column, records[0][i])
+				}
+			}
+
+			// Oldest first, so the multi line order is last
+			if last := records[len(records)-1]; last[0] != multiLine.ID.String() {
+				t.Errorf("expected the last row to be of order %s, got %v", multiLine.ID, last)
+			}
+		})
+	}
+
+	write, err := service.ExportOrders(payload.ExportOrders{Format: payload.ExportFormatXLSX, Query: payload.ListOrders{MinItemsCount: &minItemsCount}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := readXLSXRows(t, buf.Bytes())
+	if len(rows) != 2 || rows[0][0] != "order_id" || rows[1][0] != multiLine.ID.String() {
+		t.Fatalf("expected the header and the multi line order, got %v", rows)
+	}
+	if rows[1][10] != "2x5000, 1x2000, 1x250; 1x250" || rows[1][6] != "12002" {
+		t.Errorf("expected 12002 items packed as both lines, got %v", rows[1])
+	}
+}
+
+// readXLSXRows reads the values of the single sheet of a workbook.
+func readXLSXRows(t *testing.T, data []byte) [][]string {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open the workbook: %v", err)
+	}
+
+	parts := map[string]*zip.File{}
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if parts[name] == nil {
+			t.Fatalf("expected the workbook to have %s", name)
+		}
+	}
+
+	sheet, err := parts["xl/worksheets/sheet1.xml"].Open()
+	if err != nil {
+		t.Fatalf("failed to open the sheet: %v", err)
+	}
+	defer sheet.Close()
+
+	content, err := io.ReadAll(sheet)
+	if err != nil {
+		t.Fatalf("failed to read the sheet: %v", err)
+	}
+
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(content, &worksheet); err != nil {
+		t.Fatalf("failed to parse the sheet: %v", err)
+	}
+
+	rows := make([][]string, len(worksheet.Rows))
+	for i, row := range worksheet.Rows {
+		for _, cell := range row.Cells {
+			rows[i] = append(rows[i], cell.Value+cell.Inline)
+		}
+	}
+
+	return rows
+}
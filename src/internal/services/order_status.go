@@ -0,0 +1,74 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// orderStatusTransitions lists the statuses an order can move to from every
+// status. Shipped and cancelled orders stay as they are.
+var orderStatusTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusQuoted:    {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusPacked, models.OrderStatusCancelled},
+	models.OrderStatusPacked:    {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:   {},
+	models.OrderStatusCancelled: {},
+}
+
+// UpdateOrderStatus moves the order to the given status when its lifecycle
+// allows it and records who did it in the order's status history. The
+// packs of a cancelled order go back in stock.
+func (s *OrdersService) UpdateOrderStatus(orderID uuid.UUID, input payload.UpdateOrderStatus) (models.Order, error) {
+	if _, exists := orderStatusTransitions[input.Status]; !exists {
+		return models.Order{}, payload.ErrInvalidOrderStatus
+	}
+
+	if strings.TrimSpace(input.ChangedBy) == "" {
+		return models.Order{}, payload.ErrMissingChangedBy
+	}
+
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if !slices.Contains(orderStatusTransitions[order.Status], input.Status) {
+		return models.Order{}, payload.ErrInvalidStatusTransition
+	}
+
+	updated, err := s.ordersRepository.UpdateOrderStatus(models.OrderStatusChange{
+		ID:         uuid.New(),
+		OrderID:    orderID,
+		FromStatus: order.Status,
+		ToStatus:   input.Status,
+		ChangedBy:  input.ChangedBy,
+		ChangedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.Order{}, payload.ErrOrderNotFound
+		}
+
+		return models.Order{}, err
+	}
+
+	return updated, nil
+}
+
+// GetOrderStatusHistory returns the status changes of the order from the
+// oldest.
+func (s *OrdersService) GetOrderStatusHistory(orderID uuid.UUID) ([]models.OrderStatusChange, error) {
+	if _, err := s.GetOrder(orderID); err != nil {
+		return nil, err
+	}
+
+	return s.ordersRepository.GetOrderStatusHistory(orderID.String())
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestOrdersService_UpdateOrderStatus(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	testCases := []struct {
+		name          string
+		statuses      []models.OrderStatus
+		expectedError error
+	}{
+		{
+			name:     "Full lifecycle",
+			statuses: []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPacked, models.OrderStatusShipped},
+		},
+		{
+			name:     "Cancelled once packed",
+			statuses: []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPacked, models.OrderStatusCancelled},
+		},
+		{
+			name:          "Skipping a status",
+			statuses:      []models.OrderStatus{models.OrderStatusPacked},
+			expectedError: payload.ErrInvalidStatusTransition,
+		},
+		{
+			name:          "Going back",
+			statuses:      []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusQuoted},
+			expectedError: payload.ErrInvalidStatusTransition,
+		},
+		{
+			name:          "Cancelled once shipped",
+			statuses:      []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPacked, models.OrderStatusShipped, models.OrderStatusCancelled},
+			expectedError: payload.ErrInvalidStatusTransition,
+		},
+		{
+			name:          "Unknown status",
+			statuses:      []models.OrderStatus{"lost"},
+			expectedError: payload.ErrInvalidOrderStatus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+			if order.Status != models.OrderStatusQuoted {
+				t.Errorf("expected a new order to be quoted, got %q", order.Status)
+			}
+
+			applied := 0
+			for _, status := range tc.statuses {
+				updated, err := service.UpdateOrderStatus(order.ID, payload.UpdateOrderStatus{Status: status, ChangedBy: "support"})
+				if err != nil {
+					if !errors.Is(err, tc.expectedError) {
+						t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+					}
+					break
+				}
+
+				if updated.Status != status || updated.UpdatedAt.Before(order.UpdatedAt) {
+					t.Errorf("expected status %q updated after %v, got %q at %v", status, order.UpdatedAt, updated.Status, updated.UpdatedAt)
+				}
+				order = updated
+				applied++
+			}
+			if tc.expectedError != nil && applied == len(tc.statuses) {
+				t.Fatalf("expected error %v, got none", tc.expectedError)
+			}
+
+			history, err := service.GetOrderStatusHistory(order.ID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(history) != applied {
+				t.Fatalf("expected %d status changes, got %d", applied, len(history))
+			}
+
+			from := models.OrderStatusQuoted
+			for i, change := range history {
+				if change.FromStatus != from || change.ToStatus != tc.statuses[i] || change.ChangedBy != "support" {
+					t.Errorf("expected change %d from %q to %q by support, got %+v", i, from, tc.statuses[i], change)
+				}
+				from = change.ToStatus
+			}
+		})
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 251})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	_, err = service.UpdateOrderStatus(order.ID, payload.UpdateOrderStatus{Status: models.OrderStatusConfirmed})
+	if !errors.Is(err, payload.ErrMissingChangedBy) {
+		t.Errorf("expected ErrMissingChangedBy, got %v", err)
+	}
+}
+
+func TestOrdersService_CancelOrderReturnsStock(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepositoryWithStock(packSizesRepo),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	var packSize models.PackSize
+	for _, ps := range packSizes {
+		if ps.Size == 5000 {
+			packSize = ps
+		}
+	}
+
+	stock := 2
+	if _, err := packSizesService.SetPackSizeStock(packSize.ID, &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 10000})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	if _, err := service.UpdateOrderStatus(order.ID, payload.UpdateOrderStatus{Status: models.OrderStatusCancelled, ChangedBy: "support"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	packSizes, err = packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+	for _, ps := range packSizes {
+		if ps.ID == packSize.ID && (ps.Stock == nil || *ps.Stock != 2) {
+			t.Errorf("expected the cancelled order's packs back in stock, got %v", ps.Stock)
+		}
+	}
+}
@@ -2,52 +2,135 @@ package services
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
 )
 
 type PackCombinationResult struct {
-	Packs      map[int]int // key: number of packs, value: pack size
+	Packs      map[int]int // key: pack size, value: number of packs
 	TotalPacks int
+	TotalItems int
 }
 
+const (
+	defaultOrdersLimit = 50
+	maxOrdersLimit     = 500
+)
+
 type OrdersRepository interface {
-	GetAllOrders() ([]models.Order, error)
+	ListOrders(query payload.ListOrders) ([]models.Order, error)
+	CountOrders(query payload.ListOrders) (int, error)
 	SaveOrder(order models.Order) (models.Order, error)
+	SaveOrders(orders []models.Order) ([]models.Order, error)
+	SaveOrderWithIdempotencyKey(order models.Order, key models.IdempotencyKey) (models.Order, error)
+	FetchIdempotencyKey(key string, at time.Time) (models.IdempotencyKey, error)
 	FetchOrder(orderID string) (models.Order, error)
+	UpdateOrderStatus(change models.OrderStatusChange) (models.Order, error)
+	GetOrderStatusHistory(orderID string) ([]models.OrderStatusChange, error)
+	AmendOrder(order models.Order, revision models.OrderRevision) (models.Order, error)
+	GetOrderRevisions(orderID string) ([]models.OrderRevision, error)
 }
 
 type OrdersService struct {
 	ordersRepository OrdersRepository
 	packSizesRepo    PackSizeRepository
+	productsRepo     ProductsRepository
+	config           config.OrdersConfig
+	solverTables     *solverCache
+	strategies       map[string]PackingStrategy
 }
 
-func NewOrdersService(ordersRepository OrdersRepository, packSizesRepo PackSizeRepository) *OrdersService {
+func NewOrdersService(
+	ordersRepository OrdersRepository,
+	packSizesRepo PackSizeRepository,
+	productsRepo ProductsRepository,
+	cfg config.OrdersConfig,
+) *OrdersService {
+	solverTables := newSolverCache()
+
 	ordersService := &OrdersService{
 		ordersRepository: ordersRepository,
 		packSizesRepo:    packSizesRepo,
+		productsRepo:     productsRepo,
+		config:           cfg,
+		solverTables:     solverTables,
+		strategies:       newPackingStrategies(solverTables, cfg.MaxBoundedItemsCount),
 	}
 
 	return ordersService
 }
 
-func (s *OrdersService) GetAllOrders() ([]models.Order, error) {
-	orders, err := s.ordersRepository.GetAllOrders()
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
-			return []models.Order{}, payload.ErrOrderNotFound
+// InvalidateSolverCache drops the solver tables built for the product's
+// previous orders, or for every product's with uuid.Nil. Tables are keyed by
+// their pack sizes so they're never stale; it's meant to be registered with
+// PackSizesService.OnChange so tables of pack sizes that are no longer in
+// use don't pile up.
+func (s *OrdersService) InvalidateSolverCache(productID uuid.UUID) {
+	s.solverTables.invalidate(productID)
+}
+
+// ListOrders returns a page of the orders matching the filters of the
+// query, sorted by creation time from the newest unless sorted otherwise.
+// The limit defaults to defaultOrdersLimit and is capped at maxOrdersLimit.
+func (s *OrdersService) ListOrders(query payload.ListOrders) (payload.OrdersPage, error) {
+	if query.Sort == "" {
+		query.Sort = "-" + payload.OrderSortCreatedAt
+	}
+	if field, _ := query.SortField(); !slices.Contains(payload.OrderSortFields, field) {
+		return payload.OrdersPage{}, payload.ErrInvalidOrderSort
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultOrdersLimit
+	}
+	limit := min(query.Limit, maxOrdersLimit)
+
+	if query.Cursor != "" {
+		after, err := decodeOrderCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return payload.OrdersPage{}, err
 		}
+		query.After = &after
+	}
 
-		return []models.Order{}, err
+	// One more order than asked for tells whether there's a next page
+	query.Limit = limit + 1
+	orders, err := s.ordersRepository.ListOrders(query)
+	if err != nil {
+		return payload.OrdersPage{}, err
 	}
 
-	return orders, nil
+	totalCount, err := s.ordersRepository.CountOrders(query)
+	if err != nil {
+		return payload.OrdersPage{}, err
+	}
+
+	page := payload.OrdersPage{Orders: orders, TotalCount: totalCount}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+
+		cursor, err := encodeOrderCursor(page.Orders[limit-1], query.Sort)
+		if err != nil {
+			return payload.OrdersPage{}, err
+		}
+		page.NextCursor = &cursor
+	}
+
+	return page, nil
 }
 
 func (s *OrdersService) GetOrder(orderID uuid.UUID) (models.Order, error) {
@@ -63,21 +146,461 @@ func (s *OrdersService) GetOrder(orderID uuid.UUID) (models.Order, error) {
 	return order, nil
 }
 
-func (s *OrdersService) CreateOrder(itemsCount int) (models.Order, error) {
-	packSizes, err := s.packSizesRepo.GetAllPackSizes()
+// QuoteOrder calculates the pack breakdown of every line of the order
+// against its product's current pack sizes without persisting anything.
+// CreateOrder goes through this same path so a quote and an order always
+// agree. The packs of all lines are then split into shipments within the
+// carrier limits and the handling cost is charged once per shipment.
+func (s *OrdersService) QuoteOrder(input payload.CreateOrder) (payload.Quote, error) {
+	return s.quoteOrder(input, make(map[uuid.UUID]int), s.readPacking)
+}
+
+// quoteOrder quotes the order with the packs in reserved taken out of
+// stock. A negative amount hands packs back, like the ones held by an order
+// that's being amended. The packs of every line are added to reserved.
+func (s *OrdersService) quoteOrder(input payload.CreateOrder, reserved map[uuid.UUID]int, read packingReader) (payload.Quote, error) {
+	lines, err := orderLines(input)
+	if err != nil {
+		return payload.Quote{}, err
+	}
+
+	if s.config.MaxItemsCount > 0 {
+		itemsCount := 0
+		for _, line := range lines {
+			itemsCount += line.ItemsCount
+		}
+
+		if itemsCount > s.config.MaxItemsCount {
+			return payload.Quote{}, payload.ErrTooManyItems
+		}
+	}
+
+	strategy, err := packingStrategy(s.strategies, input.Strategy, s.config.DefaultStrategy)
+	if err != nil {
+		return payload.Quote{}, err
+	}
+
+	quote := payload.Quote{
+		Packs:    []models.OrderPack{},
+		Catalog:  []models.PackSize{},
+		Strategy: strategy.Name(),
+		Lines:    make([]payload.QuoteLine, 0, len(lines)),
+	}
+
+	// Packs taken by previous lines aren't available to the next ones
+	for _, line := range lines {
+		quoteLine, err := s.quoteLine(line, strategy, reserved, read, input.Explain)
+		if err != nil {
+			return payload.Quote{}, err
+		}
+
+		quote.ItemsCount += quoteLine.ItemsCount
+		quote.ShippedItems += quoteLine.ShippedItems
+		quote.Overshoot += quoteLine.Overshoot
+		quote.TotalPacks += quoteLine.TotalPacks
+		quote.TotalCostCents += quoteLine.TotalCostCents
+		quote.TotalWeightGrams += quoteLine.TotalWeightGrams
+		quote.TotalVolumeMm3 += quoteLine.TotalVolumeMm3
+		quote.Lines = append(quote.Lines, quoteLine)
+	}
+
+	quote.Shipments, err = splitShipments(quote.Lines, s.config.Shipping)
+	if err != nil {
+		return payload.Quote{}, err
+	}
+
+	quote.HandlingCostCents = s.config.HandlingCostCents * len(quote.Shipments)
+	quote.TotalCostCents += quote.HandlingCostCents
+
+	// A single line order keeps its breakdown at the top level as well
+	if len(quote.Lines) == 1 {
+		line := quote.Lines[0]
+
+		quote.ProductID = &line.ProductID
+		quote.PackSetup = line.PackSetup
+		quote.Packs = line.Packs
+		quote.Catalog = line.Catalog
+		quote.CatalogID = line.CatalogID
+		quote.Explanation = line.Explanation
+	}
+
+	return quote, nil
+}
+
+func (s *OrdersService) quoteLine(
+	line payload.OrderLine,
+	strategy PackingStrategy,
+	reserved map[uuid.UUID]int,
+	read packingReader,
+	explain bool,
+) (payload.QuoteLine, error) {
+	productID := line.ProductID
+	if productID == uuid.Nil {
+		productID = models.DefaultProductID
+	}
+
+	packing, err := read(productID)
+	if err != nil {
+		return payload.QuoteLine{}, err
+	}
+
+	catalog := snapshotCatalog(packing.catalog.PackSizes)
+	available := withinWeightLimit(applyStock(catalog, packing.packSizes, reserved), s.config.Shipping)
+	if len(available) == 0 && len(catalog) > 0 {
+		return payload.QuoteLine{}, payload.ErrPackTooHeavy
+	}
+
+	itemsCount := line.ItemsCount
+	combination, err := strategy.Calculate(itemsCount, available)
+	if err != nil {
+		return payload.QuoteLine{}, err
+	}
+
+	var explanation *models.PackingExplanation
+	if explain {
+		explanation, err = s.explainPacking(itemsCount, available, catalog, strategy, combination)
+		if err != nil {
+			return payload.QuoteLine{}, err
+		}
+	}
+
+	packs := formatPacks(combination.Packs, catalog)
+	for _, pack := range packs {
+		reserved[pack.PackSizeID] += pack.Quantity
+	}
+
+	return payload.QuoteLine{
+		ProductID:        productID,
+		ItemsCount:       itemsCount,
+		PackSetup:        formatPackSetup(packs),
+		Packs:            packs,
+		ShippedItems:     combination.TotalItems,
+		Overshoot:        max(combination.TotalItems-itemsCount, 0),
+		TotalPacks:       combination.TotalPacks,
+		Catalog:          catalog,
+		CatalogID:        catalogID(packing.catalog),
+		TotalCostCents:   packsTotal(packs, func(pack models.OrderPack) int { return pack.UnitCostCents }),
+		TotalWeightGrams: packsTotal(packs, func(pack models.OrderPack) int { return pack.WeightGrams }),
+		TotalVolumeMm3:   packsTotal(packs, func(pack models.OrderPack) int { return pack.VolumeMm3 }),
+		Explanation:      explanation,
+	}, nil
+}
+
+// productPacking is what the lines of a product are packed with: its
+// current catalog and its pack sizes with their stock.
+type productPacking struct {
+	catalog   models.PackSizeCatalog
+	packSizes []models.PackSize
+}
+
+type packingReader func(productID uuid.UUID) (productPacking, error)
+
+func (s *OrdersService) readPacking(productID uuid.UUID) (productPacking, error) {
+	if _, err := fetchProduct(s.productsRepo, productID); err != nil {
+		return productPacking{}, err
+	}
+
+	catalog, err := getCatalogAt(s.packSizesRepo, productID, time.Now())
+	if err != nil {
+		return productPacking{}, err
+	}
+
+	packSizes, err := s.packSizesRepo.GetAllPackSizes(productID.String())
+	if err != nil {
+		return productPacking{}, err
+	}
+
+	return productPacking{catalog: catalog, packSizes: packSizes}, nil
+}
+
+// cachedPacking reads the packing of every product once, so a batch of
+// orders is packed against the same catalog. Failed reads aren't cached.
+func cachedPacking(read packingReader) packingReader {
+	cache := make(map[uuid.UUID]productPacking)
+
+	return func(productID uuid.UUID) (productPacking, error) {
+		if packing, exists := cache[productID]; exists {
+			return packing, nil
+		}
+
+		packing, err := read(productID)
+		if err != nil {
+			return productPacking{}, err
+		}
+
+		cache[productID] = packing
+		return packing, nil
+	}
+}
+
+// CreateOrder quotes the order and saves it, taking its packs out of stock.
+// Requests with an idempotency key create a single order per key.
+func (s *OrdersService) CreateOrder(input payload.CreateOrder) (models.Order, error) {
+	if input.IdempotencyKey != "" {
+		return s.createIdempotentOrder(input)
+	}
+
+	quote, err := s.QuoteOrder(input)
 	if err != nil {
 		return models.Order{}, err
 	}
 
-	combination := calculatePackCombination(itemsCount, formatPackSizes(packSizes))
+	saved, err := s.ordersRepository.SaveOrder(newOrder(quote, time.Now().UTC()))
+	if err != nil {
+		return models.Order{}, err
+	}
 
+	return withExplanations(saved, quote), nil
+}
+
+// newOrder is the quoted order of a quote, created at the given time.
+func newOrder(quote payload.Quote, now time.Time) models.Order {
+	order := orderFromQuote(uuid.New(), quote)
+	order.Status = models.OrderStatusQuoted
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	return order
+}
+
+// orderFromQuote turns the breakdown of a quote into the order with the
+// given ID. Status and timestamps are left to the caller.
+func orderFromQuote(orderID uuid.UUID, quote payload.Quote) models.Order {
 	order := models.Order{
-		ID:         uuid.New(),
-		ItemsCount: itemsCount,
-		PackSetup:  formatPackSetup(combination.Packs),
+		ID:                orderID,
+		ProductID:         quote.ProductID,
+		ItemsCount:        quote.ItemsCount,
+		PackSetup:         quote.PackSetup,
+		Packs:             quote.Packs,
+		ShippedItems:      quote.ShippedItems,
+		Overshoot:         quote.Overshoot,
+		TotalPacks:        quote.TotalPacks,
+		Catalog:           quote.Catalog,
+		CatalogID:         quote.CatalogID,
+		Strategy:          quote.Strategy,
+		HandlingCostCents: quote.HandlingCostCents,
+		TotalCostCents:    quote.TotalCostCents,
+		TotalWeightGrams:  quote.TotalWeightGrams,
+		TotalVolumeMm3:    quote.TotalVolumeMm3,
+		Shipments:         quote.Shipments,
+		Lines:             make([]models.OrderLine, len(quote.Lines)),
+	}
+
+	for i, line := range quote.Lines {
+		order.Lines[i] = models.OrderLine{
+			ID:               uuid.New(),
+			OrderID:          order.ID,
+			LineNumber:       i + 1,
+			ProductID:        line.ProductID,
+			ItemsCount:       line.ItemsCount,
+			PackSetup:        line.PackSetup,
+			Packs:            line.Packs,
+			ShippedItems:     line.ShippedItems,
+			Overshoot:        line.Overshoot,
+			TotalPacks:       line.TotalPacks,
+			Catalog:          line.Catalog,
+			CatalogID:        line.CatalogID,
+			TotalCostCents:   line.TotalCostCents,
+			TotalWeightGrams: line.TotalWeightGrams,
+			TotalVolumeMm3:   line.TotalVolumeMm3,
+		}
+	}
+
+	return order
+}
+
+// withExplanations puts the explanations of the quote back on the saved
+// order, since they aren't stored.
+func withExplanations(order models.Order, quote payload.Quote) models.Order {
+	order.Explanation = quote.Explanation
+	for i := range order.Lines {
+		order.Lines[i].Explanation = quote.Lines[i].Explanation
 	}
 
-	return s.ordersRepository.SaveOrder(order)
+	return order
+}
+
+// RecalculateOrder quotes an existing order again against the current pack
+// sizes and reports whether the catalog or the resulting breakdown of any
+// line changed since the order was created. The stored order is left
+// untouched.
+func (s *OrdersService) RecalculateOrder(orderID uuid.UUID) (payload.OrderRecalculation, error) {
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return payload.OrderRecalculation{}, err
+	}
+
+	input := payload.CreateOrder{
+		Lines:    make([]payload.OrderLine, len(order.Lines)),
+		Strategy: order.Strategy,
+	}
+	for i, line := range order.Lines {
+		input.Lines[i] = payload.OrderLine{ProductID: line.ProductID, ItemsCount: line.ItemsCount}
+	}
+
+	quote, err := s.QuoteOrder(input)
+	if err != nil {
+		return payload.OrderRecalculation{}, err
+	}
+
+	recalculation := payload.OrderRecalculation{
+		Order:   order,
+		Current: quote,
+	}
+
+	for i, line := range order.Lines {
+		current := quote.Lines[i]
+
+		recalculation.CatalogChanged = recalculation.CatalogChanged || !sameCatalog(line.Catalog, current.Catalog)
+		recalculation.PacksChanged = recalculation.PacksChanged || current.PackSetup != formatPackSetup(line.Packs)
+	}
+
+	return recalculation, nil
+}
+
+// orderLines returns the lines of the order, turning the single product
+// form into a one line order. Every line must have a positive items count,
+// or a negative line could hide a huge one from the items count cap.
+func orderLines(input payload.CreateOrder) ([]payload.OrderLine, error) {
+	lines := input.Lines
+	if len(lines) == 0 {
+		lines = []payload.OrderLine{{ProductID: input.ProductID, ItemsCount: input.ItemsCount}}
+	} else if input.ProductID != uuid.Nil || input.ItemsCount != 0 {
+		return nil, payload.ErrAmbiguousOrderLines
+	}
+
+	for _, line := range lines {
+		if line.ItemsCount <= 0 {
+			return nil, payload.ErrInvalidItemsCount
+		}
+	}
+
+	return lines, nil
+}
+
+// encodeOrderCursor makes the cursor of the page that ends with the order.
+func encodeOrderCursor(order models.Order, sort string) (string, error) {
+	data, err := json.Marshal(orderCursor(order, sort))
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// orderCursor is the position of the order in the orders sorted by sort.
+func orderCursor(order models.Order, sort string) payload.OrderCursor {
+	cursor := payload.OrderCursor{Sort: sort, ID: order.ID}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case payload.OrderSortCreatedAt:
+		cursor.Value = order.CreatedAt.Format(time.RFC3339Nano)
+	case payload.OrderSortItemsCount:
+		cursor.Value = strconv.Itoa(order.ItemsCount)
+	case payload.OrderSortShippedItems:
+		cursor.Value = strconv.Itoa(order.ShippedItems)
+	case payload.OrderSortTotalPacks:
+		cursor.Value = strconv.Itoa(order.TotalPacks)
+	case payload.OrderSortTotalCostCents:
+		cursor.Value = strconv.Itoa(order.TotalCostCents)
+	}
+
+	return cursor
+}
+
+// decodeOrderCursor reads a cursor made by encodeOrderCursor, which must
+// have been made with the same sort.
+func decodeOrderCursor(raw string, sort string) (payload.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return payload.OrderCursor{}, payload.ErrInvalidCursor
+	}
+
+	var cursor payload.OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort {
+		return payload.OrderCursor{}, payload.ErrInvalidCursor
+	}
+
+	if _, err := cursor.SortValue(); err != nil {
+		return payload.OrderCursor{}, payload.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// applyStock returns the pack sizes of the catalog a strategy can choose
+// from, with their current stock less the packs reserved by previous lines
+// of the order. Sizes that were deleted since the catalog was published have
+// no stock left. The catalog itself is left without stock, as it's stored
+// with the order.
+func applyStock(catalog []models.PackSize, packSizes []models.PackSize, reserved map[uuid.UUID]int) []models.PackSize {
+	current := make(map[uuid.UUID]models.PackSize, len(packSizes))
+	for _, ps := range packSizes {
+		current[ps.ID] = ps
+	}
+
+	available := make([]models.PackSize, len(catalog))
+	for i, ps := range catalog {
+		available[i] = ps
+
+		currentPackSize, exists := current[ps.ID]
+		if !exists {
+			noStock := 0
+			currentPackSize.Stock = &noStock
+		}
+
+		if currentPackSize.Stock != nil {
+			left := max(*currentPackSize.Stock-reserved[ps.ID], 0)
+			available[i].Stock = &left
+		}
+	}
+
+	return available
+}
+
+// snapshotCatalog copies the pack sizes used for a calculation, ordered by
+// size, so they can be stored alongside the order. Stock changes with every
+// order so it's left out of the copy.
+func snapshotCatalog(packSizes []models.PackSize) []models.PackSize {
+	catalog := make([]models.PackSize, len(packSizes))
+	copy(catalog, packSizes)
+	for i := range catalog {
+		catalog[i].Stock = nil
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Size < catalog[j].Size
+	})
+
+	return catalog
+}
+
+// catalogID returns nil for the empty catalog used before any version
+// was published.
+func catalogID(catalog models.PackSizeCatalog) *uuid.UUID {
+	if catalog.ID == uuid.Nil {
+		return nil
+	}
+
+	return &catalog.ID
+}
+
+// sameCatalog compares two catalog snapshots. Stock isn't part of the
+// catalog so it's left out.
+func sameCatalog(a, b []models.PackSize) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		x, y := a[i], b[i]
+		x.Stock, y.Stock = nil, nil
+		if x != y {
+			return false
+		}
+	}
+
+	return true
 }
 
 func formatPackSizes(packSizes []models.PackSize) []int {
@@ -89,15 +612,54 @@ func formatPackSizes(packSizes []models.PackSize) []int {
 	return sizes
 }
 
-// We save the pack setup as a formatted string like "2x500, 1x1000"
-// which is not optimal for querying but works for demonstration purposes.
-func formatPackSetup(packs map[int]int) string {
-	result := ""
+// formatPacks turns the combination map into the structured breakdown
+// stored with the order, ordered from the largest pack size to the smallest
+// and pointing at the catalog's pack sizes, prices, weights and volumes.
+func formatPacks(packs map[int]int, catalog []models.PackSize) []models.OrderPack {
+	bySize := make(map[int]models.PackSize, len(catalog))
+	for _, ps := range catalog {
+		bySize[ps.Size] = ps
+	}
+
+	result := make([]models.OrderPack, 0, len(packs))
 	for size, count := range packs {
+		result = append(result, models.OrderPack{
+			PackSizeID:    bySize[size].ID,
+			PackSize:      size,
+			Quantity:      count,
+			UnitCostCents: bySize[size].UnitCostCents,
+			WeightGrams:   bySize[size].WeightGrams,
+			VolumeMm3:     bySize[size].VolumeMm3(),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PackSize > result[j].PackSize
+	})
+
+	return result
+}
+
+// packsTotal sums the given per pack value over all the packs.
+func packsTotal(packs []models.OrderPack, value func(models.OrderPack) int) int {
+	total := 0
+	for _, pack := range packs {
+		total += pack.Quantity * value(pack)
+	}
+
+	return total
+}
+
+// The legacy pack setup is derived from the structured breakdown as a
+// formatted string like "1x1000, 2x500" and is kept for backwards
+// compatibility with consumers that still parse it.
+func formatPackSetup(packs []models.OrderPack) string {
+	result := ""
+	for _, pack := range packs {
 		if result != "" {
 			result += ", "
 		}
-		result += fmt.Sprintf("%dx%d", count, size)
+		result += fmt.Sprintf("%dx%d", pack.Quantity, pack.PackSize)
 	}
 
 	return result
@@ -106,35 +668,293 @@ func formatPackSetup(packs map[int]int) string {
 // We're using a dynamic programming with greedy optimization approach
 // to find the optimal pack combination that meets or exceeds
 // the itemsCount with the least number of packs and items.
-func calculatePackCombination(itemsCount int, packSizes []int) PackCombinationResult {
+// Pack sizes can come in any order, and payload.ErrNoPackSizes is returned
+// when there are none to pack the items with.
+func calculatePackCombination(itemsCount int, packSizes []int) (PackCombinationResult, error) {
+	return solveUnbounded(itemsCount, packSizes, productTables{}, findBestTarget)
+}
+
+// targetSelector picks which reachable amount of items, between start and
+// end, the packs should add up to. It returns -1 when none is reachable.
+type targetSelector func(dp []int, start, end int) int
+
+// packingSpace holds the fewest packs that make every amount of items a line
+// can be shipped with, from the items ordered up to one largest pack more, in
+// ascending order. Amounts no packing can make are left out.
+type packingSpace struct {
+	amounts     []int
+	packs       []int
+	combination func(amount int) map[int]int
+}
+
+// result returns the packing of the i-th amount of the space.
+func (s packingSpace) result(i int) PackCombinationResult {
+	return PackCombinationResult{
+		Packs:      s.combination(s.amounts[i]),
+		TotalPacks: s.packs[i],
+		TotalItems: s.amounts[i],
+	}
+}
+
+// solveUnbounded picks the target out of the unbounded packing space of
+// the pack sizes.
+func solveUnbounded(itemsCount int, packSizes []int, tables productTables, selectTarget targetSelector) (PackCombinationResult, error) {
 	if itemsCount <= 0 {
-		return PackCombinationResult{Packs: make(map[int]int)}
+		return PackCombinationResult{Packs: make(map[int]int)}, nil
+	}
+
+	space, err := unboundedSpace(itemsCount, packSizes, tables)
+	if err != nil {
+		return PackCombinationResult{}, err
+	}
+
+	bestTarget := selectTarget(space.packs, 0, len(space.packs)-1)
+	if bestTarget == -1 {
+		// Fallback (shouldn't happen with these pack sizes)
+		return PackCombinationResult{Packs: make(map[int]int), TotalPacks: 0}, nil
+	}
+
+	return space.result(bestTarget), nil
+}
+
+// unboundedSpace works in units of the gcd of the pack sizes. Targets past
+// the residue table's threshold are solved straight from the table, so an
+// order of any size takes memory and time bounded by the pack sizes alone.
+// Smaller orders, where that doesn't hold, fall back to the plain table,
+// which is then bounded by the threshold. The residue table comes from the
+// given product's cache.
+func unboundedSpace(itemsCount int, packSizes []int, tables productTables) (packingSpace, error) {
+	packSizes = normalizePackSizes(packSizes)
+	if len(packSizes) == 0 {
+		return packingSpace{}, payload.ErrNoPackSizes
+	}
+
+	table := tables.table(packSizes)
+
+	// Find the maximum reasonable target (itemsCount + largest pack)
+	start := (itemsCount + table.gcd - 1) / table.gcd
+	end := (itemsCount + table.largest*table.gcd) / table.gcd
+
+	if start < table.threshold {
+		return smallUnboundedSpace(start, end, packSizes, table.gcd), nil
+	}
+
+	space := packingSpace{
+		amounts: make([]int, 0, end-start+1),
+		packs:   make([]int, 0, end-start+1),
+		combination: func(amount int) map[int]int {
+			return table.combination(amount / table.gcd)
+		},
+	}
+	for target := start; target <= end; target++ {
+		if packs := table.packs(target); packs != math.MaxInt32 {
+			space.amounts = append(space.amounts, target*table.gcd)
+			space.packs = append(space.packs, packs)
+		}
+	}
+
+	return space, nil
+}
+
+func smallUnboundedSpace(start, end int, packSizes []int, g int) packingSpace {
+	sizes := make([]int, len(packSizes))
+	for i, size := range packSizes {
+		sizes[i] = size / g
+	}
+
+	dp, parent := buildDPAndParent(end, sizes)
+
+	space := packingSpace{
+		amounts: make([]int, 0, end-start+1),
+		packs:   make([]int, 0, end-start+1),
+		// Backtrack to reconstruct the solution
+		combination: func(amount int) map[int]int {
+			packs := make(map[int]int)
+			current := amount / g
+			for current > 0 && parent[current] != -1 {
+				packSize := parent[current]
+				packs[packSize*g]++
+				current -= packSize
+			}
+
+			return packs
+		},
+	}
+	for target := start; target <= end; target++ {
+		if dp[target] != math.MaxInt32 {
+			space.amounts = append(space.amounts, target*g)
+			space.packs = append(space.packs, dp[target])
+		}
 	}
 
-	// Find the maximum reasonable target (itemsCount + largest pack - 1)
-	maxTarget := itemsCount + packSizes[len(packSizes)-1]
+	return space
+}
 
-	dp, parent := buildDPAndParent(maxTarget, packSizes)
+// calculatePackCombinationWithStock is the inventory-aware variant of
+// calculatePackCombination: a size in stock can't be used more times than
+// there are packs of it left, while sizes missing from stock are unlimited.
+// It returns payload.ErrInsufficientStock when no packing is possible.
+func calculatePackCombinationWithStock(itemsCount int, packSizes []int, stock map[int]int) (PackCombinationResult, error) {
+	return solveWithStock(itemsCount, packSizes, stock, productTables{}, 0, findBestTarget)
+}
 
-	bestTarget := findBestTarget(dp, itemsCount, maxTarget)
+// solveWithStock solves with the unbounded solver when no stock is tracked,
+// otherwise with the bounded one, which refuses items counts over
+// maxBoundedItems, zero meaning no cap.
+func solveWithStock(
+	itemsCount int,
+	packSizes []int,
+	stock map[int]int,
+	tables productTables,
+	maxBoundedItems int,
+	selectTarget targetSelector,
+) (PackCombinationResult, error) {
+	if itemsCount <= 0 {
+		return PackCombinationResult{Packs: make(map[int]int)}, nil
+	}
 
+	if len(stock) == 0 {
+		return solveUnbounded(itemsCount, packSizes, tables, selectTarget)
+	}
+
+	space, err := boundedSpace(itemsCount, packSizes, stock, maxBoundedItems)
+	if err != nil {
+		return PackCombinationResult{}, err
+	}
+
+	bestTarget := selectTarget(space.packs, 0, len(space.packs)-1)
 	if bestTarget == -1 {
-		// Fallback (shouldn't happen with these pack sizes)
-		return PackCombinationResult{Packs: make(map[int]int), TotalPacks: 0}
+		return PackCombinationResult{}, payload.ErrInsufficientStock
 	}
 
-	// Backtrack to reconstruct the solution
+	return space.result(bestTarget), nil
+}
+
+// stockSpace returns the packing space of the pack sizes with the given
+// stock, which is the unbounded one when no stock is tracked.
+func stockSpace(itemsCount int, packSizes []int, stock map[int]int, tables productTables, maxBoundedItems int) (packingSpace, error) {
+	if len(stock) == 0 {
+		return unboundedSpace(itemsCount, packSizes, tables)
+	}
+
+	return boundedSpace(itemsCount, packSizes, stock, maxBoundedItems)
+}
+
+// boundedSpace takes time and memory proportional to the items count, so
+// it refuses counts over maxBoundedItems.
+func boundedSpace(itemsCount int, packSizes []int, stock map[int]int, maxBoundedItems int) (packingSpace, error) {
+	packSizes = normalizePackSizes(packSizes)
+	if len(packSizes) == 0 {
+		return packingSpace{}, payload.ErrNoPackSizes
+	}
+
+	if err := checkBoundedItems(itemsCount, maxBoundedItems); err != nil {
+		return packingSpace{}, err
+	}
+
+	largest := packSizes[len(packSizes)-1]
+
+	// Using any pack past itemsCount + largest pack would only add items
+	maxTarget := itemsCount + largest
+
+	items := splitBoundedPacks(maxTarget, packSizes, stock)
+	dp, taken := buildBoundedDP(maxTarget, items)
+
+	space := packingSpace{
+		amounts: make([]int, 0, largest+1),
+		packs:   make([]int, 0, largest+1),
+		combination: func(amount int) map[int]int {
+			return collectBoundedPacks(items, taken, amount)
+		},
+	}
+	for target := itemsCount; target <= maxTarget; target++ {
+		if dp[target] != math.MaxInt32 {
+			space.amounts = append(space.amounts, target)
+			space.packs = append(space.packs, dp[target])
+		}
+	}
+
+	return space, nil
+}
+
+// checkBoundedItems caps the items count of the solvers whose time and
+// memory grow with it, zero meaning no cap.
+func checkBoundedItems(itemsCount, maxBoundedItems int) error {
+	if maxBoundedItems > 0 && itemsCount > maxBoundedItems {
+		return payload.ErrTooManyBoundedItems
+	}
+
+	return nil
+}
+
+// collectBoundedPacks backtracks through the bundles in reverse to
+// reconstruct the packs that add up to target.
+func collectBoundedPacks(items []boundedPack, taken [][]uint64, target int) map[int]int {
 	packs := make(map[int]int)
-	current := bestTarget
-	for current > 0 && parent[current] != -1 {
-		packSize := parent[current]
-		packs[packSize]++
-		current -= packSize
+	current := target
+	for i := len(items) - 1; i >= 0; i-- {
+		if taken[i][current/64]&(1<<(current%64)) != 0 {
+			packs[items[i].size] += items[i].count
+			current -= items[i].size * items[i].count
+		}
 	}
 
-	return PackCombinationResult{
-		Packs: packs,
+	return packs
+}
+
+// boundedPack is a bundle of count packs of the same size that is either
+// used as a whole or not at all.
+type boundedPack struct {
+	size  int
+	count int
+}
+
+// splitBoundedPacks splits the packs available of every size into bundles
+// of 1, 2, 4, ... packs plus the remainder, so any number of packs up to
+// the stock can be made of distinct bundles. Unlimited sizes are capped at
+// the most packs that fit in maxTarget.
+func splitBoundedPacks(maxTarget int, packSizes []int, stock map[int]int) []boundedPack {
+	var items []boundedPack
+	for _, size := range packSizes {
+		available, tracked := stock[size]
+		if !tracked || available > maxTarget/size {
+			available = maxTarget / size
+		}
+
+		for count := 1; available > 0; count *= 2 {
+			count = min(count, available)
+			items = append(items, boundedPack{size: size, count: count})
+			available -= count
+		}
+	}
+
+	return items
+}
+
+// buildBoundedDP runs a 0/1 knapsack over the bundles: dp[i] is the minimum
+// packs needed to make exactly i items and taken[n] is a bitset of the
+// targets whose best value used the n-th bundle.
+func buildBoundedDP(maxTarget int, items []boundedPack) ([]int, [][]uint64) {
+	dp := make([]int, maxTarget+1)
+	for i := range dp {
+		dp[i] = math.MaxInt32
 	}
+	dp[0] = 0
+
+	taken := make([][]uint64, len(items))
+	for n, item := range items {
+		taken[n] = make([]uint64, maxTarget/64+1)
+		weight := item.size * item.count
+
+		for i := maxTarget; i >= weight; i-- {
+			if dp[i-weight] != math.MaxInt32 && dp[i-weight]+item.count < dp[i] {
+				dp[i] = dp[i-weight] + item.count
+				taken[n][i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+
+	return dp, taken
 }
 
 func buildDPAndParent(maxTarget int, packSizes []int) ([]int, []int) {
@@ -177,3 +997,17 @@ func findBestTarget(dp []int, start, end int) int {
 
 	return bestTarget
 }
+
+// findFewestPacksTarget picks the target reachable with the fewest packs,
+// the smallest one among those with the same number of packs.
+func findFewestPacksTarget(dp []int, start, end int) int {
+	bestTarget := -1
+
+	for target := start; target <= end; target++ {
+		if dp[target] != math.MaxInt32 && (bestTarget == -1 || dp[target] < dp[bestTarget]) {
+			bestTarget = target
+		}
+	}
+
+	return bestTarget
+}
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
 )
 
 var defaultPackSizes = []int{250, 500, 1000, 2000, 5000}
@@ -15,6 +20,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 		name          string
 		itemsCount    int
 		expectedPacks map[int]int // pack size -> count
+		expectedTotal int
 	}{
 		{
 			name:       "Single item",
@@ -22,6 +28,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			expectedPacks: map[int]int{
 				250: 1,
 			},
+			expectedTotal: 250,
 		},
 		{
 			name:       "Exact match 250",
@@ -29,6 +36,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			expectedPacks: map[int]int{
 				250: 1,
 			},
+			expectedTotal: 250,
 		},
 		{
 			name:       "251 items - should use 1x500",
@@ -36,6 +44,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			expectedPacks: map[int]int{
 				500: 1,
 			},
+			expectedTotal: 500,
 		},
 		{
 			name:       "501 items - should use 1x500 + 1x250",
@@ -44,6 +53,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 				500: 1,
 				250: 1,
 			},
+			expectedTotal: 750,
 		},
 		{
 			name:       "Large order 12001",
@@ -53,6 +63,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 				2000: 1,
 				250:  1,
 			},
+			expectedTotal: 12250,
 		},
 		{
 			name:       "Exact match 5000",
@@ -60,6 +71,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			expectedPacks: map[int]int{
 				5000: 1,
 			},
+			expectedTotal: 5000,
 		},
 		{
 			name:       "751 items - tricky case",
@@ -67,6 +79,7 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			expectedPacks: map[int]int{
 				1000: 1,
 			},
+			expectedTotal: 1000,
 		},
 	}
 
@@ -76,9 +89,9 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			packSizesRepo := setupPackSizesRepositoryWithDefaults()
 			defer packSizesRepo.Clear()
 
-			service := NewOrdersService(repo, packSizesRepo)
+			service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
 
-			order, err := service.CreateOrder(tc.itemsCount)
+			order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: tc.itemsCount})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -92,6 +105,41 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 				t.Errorf("expected ItemsCount %d, got %d", tc.itemsCount, order.ItemsCount)
 			}
 
+			// Verify the structured breakdown matches the expected packs
+			if len(order.Packs) != len(tc.expectedPacks) {
+				t.Errorf("expected %d pack lines, got %d", len(tc.expectedPacks), len(order.Packs))
+			}
+
+			for i, pack := range order.Packs {
+				if expectedCount := tc.expectedPacks[pack.PackSize]; pack.Quantity != expectedCount {
+					t.Errorf("pack size %d: expected %d packs, got %d", pack.PackSize, expectedCount, pack.Quantity)
+				}
+
+				if i > 0 && order.Packs[i-1].PackSize < pack.PackSize {
+					t.Errorf("pack lines should be sorted by descending size, got %v", order.Packs)
+				}
+			}
+
+			// Verify the calculation metrics are stored with the order
+			expectedPacks := 0
+			for _, count := range tc.expectedPacks {
+				expectedPacks += count
+			}
+
+			if order.ShippedItems != tc.expectedTotal {
+				t.Errorf("expected ShippedItems %d, got %d", tc.expectedTotal, order.ShippedItems)
+			}
+			if order.Overshoot != tc.expectedTotal-tc.itemsCount {
+				t.Errorf("expected Overshoot %d, got %d", tc.expectedTotal-tc.itemsCount, order.Overshoot)
+			}
+			if order.TotalPacks != expectedPacks {
+				t.Errorf("expected TotalPacks %d, got %d", expectedPacks, order.TotalPacks)
+			}
+
+			if order.PackSetup != formatPackSetup(order.Packs) {
+				t.Errorf("pack setup %q does not match the structured breakdown %v", order.PackSetup, order.Packs)
+			}
+
 			// Verify order was saved in repository
 			savedOrder, err := repo.FetchOrder(order.ID.String())
 			if err != nil {
@@ -103,7 +151,10 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 			}
 
 			// Verify pack combination by calculating it again
-			combination := calculatePackCombination(tc.itemsCount, defaultPackSizes)
+			combination, err := calculatePackCombination(tc.itemsCount, defaultPackSizes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if len(combination.Packs) != len(tc.expectedPacks) {
 				t.Errorf("expected %d different pack sizes, got %d", len(tc.expectedPacks), len(combination.Packs))
 			}
@@ -128,38 +179,134 @@ func TestOrdersService_CreateOrder(t *testing.T) {
 	}
 }
 
-func TestOrdersService_GetAllOrders(t *testing.T) {
+func TestOrdersService_ListOrders(t *testing.T) {
 	repo := repositories.NewInMemoryOrdersRepository()
 	packSizesRepo := setupPackSizesRepositoryWithDefaults()
 	defer packSizesRepo.Clear()
 
-	service := NewOrdersService(repo, packSizesRepo)
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
 
-	// Initially should be empty
-	orders, err := service.GetAllOrders()
+	// Initially should be an empty page rather than an error
+	page, err := service.ListOrders(payload.ListOrders{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(orders) != 0 {
-		t.Errorf("expected 0 orders, got %d", len(orders))
+	if page.Orders == nil || len(page.Orders) != 0 || page.TotalCount != 0 || page.NextCursor != nil {
+		t.Errorf("expected an empty page, got %+v", page)
 	}
 
 	// Create some orders
-	orderCounts := []int{250, 500, 1000}
+	orderCounts := []int{1000, 250, 12001, 500}
 	for _, count := range orderCounts {
-		_, err := service.CreateOrder(count)
+		_, err := service.CreateOrder(payload.CreateOrder{ItemsCount: count})
 		if err != nil {
 			t.Fatalf("failed to create order: %v", err)
 		}
 	}
 
-	// Should now have 3 orders
-	orders, err = service.GetAllOrders()
+	intPtr := func(value int) *int { return &value }
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name          string
+		query         payload.ListOrders
+		expectedPages [][]int
+		expectedTotal int
+	}{
+		{
+			name:          "Sorted by items count in pages of two",
+			query:         payload.ListOrders{Sort: payload.OrderSortItemsCount, Limit: 2},
+			expectedPages: [][]int{{250, 500}, {1000, 12001}},
+			expectedTotal: 4,
+		},
+		{
+			name:          "Descending",
+			query:         payload.ListOrders{Sort: "-" + payload.OrderSortItemsCount, Limit: 3},
+			expectedPages: [][]int{{12001, 1000, 500}, {250}},
+			expectedTotal: 4,
+		},
+		{
+			name:          "Items count range",
+			query:         payload.ListOrders{Sort: payload.OrderSortItemsCount, MinItemsCount: intPtr(500), MaxItemsCount: intPtr(1000)},
+			expectedPages: [][]int{{500, 1000}},
+			expectedTotal: 2,
+		},
+		{
+			name:          "Pack size used",
+			query:         payload.ListOrders{PackSize: intPtr(5000)},
+			expectedPages: [][]int{{12001}},
+			expectedTotal: 1,
+		},
+		{
+			name:          "Product",
+			query:         payload.ListOrders{Sort: payload.OrderSortItemsCount, ProductID: &models.DefaultProductID},
+			expectedPages: [][]int{{250, 500, 1000, 12001}},
+			expectedTotal: 4,
+		},
+		{
+			name:          "Created range",
+			query:         payload.ListOrders{CreatedFrom: &future},
+			expectedPages: [][]int{{}},
+			expectedTotal: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := tc.query
+			for i, expected := range tc.expectedPages {
+				page, err := service.ListOrders(query)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				itemsCounts := []int{}
+				for _, order := range page.Orders {
+					itemsCounts = append(itemsCounts, order.ItemsCount)
+				}
+				if !reflect.DeepEqual(itemsCounts, expected) {
+					t.Errorf("expected page %d to be %v, got %v", i, expected, itemsCounts)
+				}
+				if page.TotalCount != tc.expectedTotal {
+					t.Errorf("expected total count %d, got %d", tc.expectedTotal, page.TotalCount)
+				}
+
+				if last := i == len(tc.expectedPages)-1; last != (page.NextCursor == nil) {
+					t.Fatalf("expected a next cursor on every page but the last, got %v on page %d", page.NextCursor, i)
+				}
+				if page.NextCursor != nil {
+					query.Cursor = *page.NextCursor
+				}
+			}
+		})
+	}
+
+	// Newest first by default
+	page, err = service.ListOrders(payload.ListOrders{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(orders) != 3 {
-		t.Errorf("expected 3 orders, got %d", len(orders))
+	for i := 1; i < len(page.Orders); i++ {
+		if page.Orders[i].CreatedAt.After(page.Orders[i-1].CreatedAt) {
+			t.Errorf("expected orders from the newest, got %v before %v", page.Orders[i-1].CreatedAt, page.Orders[i].CreatedAt)
+		}
+	}
+
+	if _, err := service.ListOrders(payload.ListOrders{Sort: "pack_setup"}); !errors.Is(err, payload.ErrInvalidOrderSort) {
+		t.Errorf("expected ErrInvalidOrderSort, got %v", err)
+	}
+
+	// A cursor only works with the sort it was made with
+	page, err = service.ListOrders(payload.ListOrders{Sort: payload.OrderSortItemsCount, Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = service.ListOrders(payload.ListOrders{Sort: payload.OrderSortTotalPacks, Cursor: *page.NextCursor})
+	if !errors.Is(err, payload.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+	if _, err := service.ListOrders(payload.ListOrders{Cursor: "not a cursor"}); !errors.Is(err, payload.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
 	}
 }
 
@@ -167,10 +314,10 @@ func TestOrdersService_GetOrder(t *testing.T) {
 	repo := repositories.NewInMemoryOrdersRepository()
 	packSizesRepo := setupPackSizesRepositoryWithDefaults()
 	defer packSizesRepo.Clear()
-	service := NewOrdersService(repo, packSizesRepo)
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
 
 	// Create an order
-	createdOrder, err := service.CreateOrder(500)
+	createdOrder, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 500})
 	if err != nil {
 		t.Fatalf("failed to create order: %v", err)
 	}
@@ -197,6 +344,618 @@ func TestOrdersService_GetOrder(t *testing.T) {
 	}
 }
 
+func TestOrdersService_QuoteOrder(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		itemsCount           int
+		expectedShippedItems int
+		expectedOvershoot    int
+		expectedTotalPacks   int
+	}{
+		{
+			name:                 "Single item",
+			itemsCount:           1,
+			expectedShippedItems: 250,
+			expectedOvershoot:    249,
+			expectedTotalPacks:   1,
+		},
+		{
+			name:                 "Exact match 250",
+			itemsCount:           250,
+			expectedShippedItems: 250,
+			expectedOvershoot:    0,
+			expectedTotalPacks:   1,
+		},
+		{
+			name:                 "501 items - should use 1x500 + 1x250",
+			itemsCount:           501,
+			expectedShippedItems: 750,
+			expectedOvershoot:    249,
+			expectedTotalPacks:   2,
+		},
+		{
+			name:                 "Large order 12001",
+			itemsCount:           12001,
+			expectedShippedItems: 12250,
+			expectedOvershoot:    249,
+			expectedTotalPacks:   4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := repositories.NewInMemoryOrdersRepository()
+			packSizesRepo := setupPackSizesRepositoryWithDefaults()
+			defer packSizesRepo.Clear()
+
+			service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
+
+			quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: tc.itemsCount})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if quote.ItemsCount != tc.itemsCount {
+				t.Errorf("expected ItemsCount %d, got %d", tc.itemsCount, quote.ItemsCount)
+			}
+			if quote.ShippedItems != tc.expectedShippedItems {
+				t.Errorf("expected ShippedItems %d, got %d", tc.expectedShippedItems, quote.ShippedItems)
+			}
+			if quote.Overshoot != tc.expectedOvershoot {
+				t.Errorf("expected Overshoot %d, got %d", tc.expectedOvershoot, quote.Overshoot)
+			}
+			if quote.TotalPacks != tc.expectedTotalPacks {
+				t.Errorf("expected TotalPacks %d, got %d", tc.expectedTotalPacks, quote.TotalPacks)
+			}
+
+			// Quotes must never be persisted
+			if repo.Count() != 0 {
+				t.Errorf("expected no orders to be saved, got %d", repo.Count())
+			}
+
+			// A later order for the same input must agree with the quote
+			order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: tc.itemsCount})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+			if order.PackSetup != quote.PackSetup {
+				t.Errorf("expected pack setup %q, got %q", quote.PackSetup, order.PackSetup)
+			}
+		})
+	}
+}
+
+func TestOrdersService_QuoteOrderMaxItemsCount(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{MaxItemsCount: 1000},
+	)
+
+	if _, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 1000}); err != nil {
+		t.Errorf("expected an order at the cap to be quoted, got %v", err)
+	}
+
+	if _, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 1001}); !errors.Is(err, payload.ErrTooManyItems) {
+		t.Errorf("expected ErrTooManyItems, got %v", err)
+	}
+
+	// The cap applies to the order as a whole
+	_, err := service.CreateOrder(payload.CreateOrder{Lines: []payload.OrderLine{{ItemsCount: 600}, {ItemsCount: 600}}})
+	if !errors.Is(err, payload.ErrTooManyItems) {
+		t.Errorf("expected ErrTooManyItems, got %v", err)
+	}
+}
+
+func TestOrdersService_QuoteOrderNonPositiveLines(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	ordersRepo := repositories.NewInMemoryOrdersRepository()
+	service := NewOrdersService(
+		ordersRepo,
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{MaxItemsCount: 10000000},
+	)
+
+	testCases := []struct {
+		name  string
+		input payload.CreateOrder
+	}{
+		{
+			name:  "Negative line hiding a line over the cap",
+			input: payload.CreateOrder{Lines: []payload.OrderLine{{ItemsCount: 19000000}, {ItemsCount: -9000000}}},
+		},
+		{
+			name:  "Zero items line",
+			input: payload.CreateOrder{Lines: []payload.OrderLine{{ItemsCount: 500}, {ItemsCount: 0}}},
+		},
+		{
+			name:  "Negative single product order",
+			input: payload.CreateOrder{ItemsCount: -1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := service.QuoteOrder(tc.input); !errors.Is(err, payload.ErrInvalidItemsCount) {
+				t.Errorf("expected ErrInvalidItemsCount quoting, got %v", err)
+			}
+			if _, err := service.CreateOrder(tc.input); !errors.Is(err, payload.ErrInvalidItemsCount) {
+				t.Errorf("expected ErrInvalidItemsCount creating, got %v", err)
+			}
+		})
+	}
+
+	if ordersRepo.Count() != 0 {
+		t.Errorf("expected no order to be saved, got %d", ordersRepo.Count())
+	}
+}
+
+func TestOrdersService_QuoteOrderMaxBoundedItemsCount(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{MaxBoundedItemsCount: 1000},
+	)
+
+	// The unbounded solver isn't capped
+	if _, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 5000}); err != nil {
+		t.Errorf("expected an order without tracked stock to be quoted, got %v", err)
+	}
+
+	_, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 5000, Strategy: StrategyCheapest})
+	if !errors.Is(err, payload.ErrTooManyBoundedItems) {
+		t.Errorf("expected ErrTooManyBoundedItems for the cheapest strategy, got %v", err)
+	}
+
+	packSizes, _ := packSizesRepo.GetAllPackSizes(models.DefaultProductID.String())
+	stock := 100
+	if _, err := packSizesRepo.SetPackSizeStock(packSizes[0].ID.String(), &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+
+	if _, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 1000}); err != nil {
+		t.Errorf("expected an order at the cap to be quoted, got %v", err)
+	}
+
+	_, err = service.QuoteOrder(payload.CreateOrder{ItemsCount: 1001})
+	if !errors.Is(err, payload.ErrTooManyBoundedItems) {
+		t.Errorf("expected ErrTooManyBoundedItems with tracked stock, got %v", err)
+	}
+}
+
+func TestOrdersService_RecalculateOrder(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 501})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	if len(order.Catalog) != len(defaultPackSizes) {
+		t.Fatalf("expected %d pack sizes in the catalog snapshot, got %d", len(defaultPackSizes), len(order.Catalog))
+	}
+
+	currentCatalog, err := packSizesRepo.GetCatalogAt(models.DefaultProductID.String(), time.Now())
+	if err != nil {
+		t.Fatalf("failed to get current catalog: %v", err)
+	}
+	if order.CatalogID == nil || *order.CatalogID != currentCatalog.ID {
+		t.Errorf("expected order to reference catalog %v, got %v", currentCatalog.ID, order.CatalogID)
+	}
+
+	// Nothing changed since the order was created
+	recalculation, err := service.RecalculateOrder(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recalculation.CatalogChanged {
+		t.Error("expected catalog to be unchanged")
+	}
+	if recalculation.PacksChanged {
+		t.Errorf("expected packs to be unchanged, got %q", recalculation.Current.PackSetup)
+	}
+
+	// Replace the 500 pack with a 600 pack
+	var packSize500 models.PackSize
+	for _, ps := range order.Catalog {
+		if ps.Size == 500 {
+			packSize500 = ps
+		}
+	}
+	packSizesService := NewPackSizesService(packSizesRepo)
+	if _, err := packSizesService.UpdatePackSize(payload.UpdatePackSize{ID: packSize500.ID, Size: 600}); err != nil {
+		t.Fatalf("failed to update pack size: %v", err)
+	}
+
+	recalculation, err = service.RecalculateOrder(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !recalculation.CatalogChanged {
+		t.Error("expected catalog to be changed")
+	}
+	if !recalculation.PacksChanged {
+		t.Error("expected packs to be changed")
+	}
+	if recalculation.Current.PackSetup != "1x600" {
+		t.Errorf("expected current pack setup 1x600, got %q", recalculation.Current.PackSetup)
+	}
+	if recalculation.Order.PackSetup != order.PackSetup {
+		t.Errorf("stored order should be untouched, expected %q, got %q", order.PackSetup, recalculation.Order.PackSetup)
+	}
+
+	// Unknown orders are reported as not found
+	if _, err := service.RecalculateOrder(uuid.New()); err == nil {
+		t.Error("expected error when recalculating non-existent order")
+	}
+}
+
+func TestOrdersService_CreateOrderIgnoresInactivePackSizes(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+	for _, ps := range packSizes {
+		if ps.Size == 250 {
+			inactive := false
+			if _, err := packSizesService.UpdatePackSize(payload.UpdatePackSize{ID: ps.ID, Active: &inactive}); err != nil {
+				t.Fatalf("failed to deactivate pack size: %v", err)
+			}
+		}
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if order.PackSetup != "1x500" {
+		t.Errorf("expected pack setup 1x500, got %q", order.PackSetup)
+	}
+	for _, ps := range order.Catalog {
+		if ps.Size == 250 {
+			t.Error("expected the inactive 250 pack to be left out of the catalog")
+		}
+	}
+}
+
+func TestOrdersService_CreateOrderTakesStock(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+	repo := repositories.NewInMemoryOrdersRepositoryWithStock(packSizesRepo)
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{})
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	bySize := make(map[int]models.PackSize)
+	for _, ps := range packSizes {
+		bySize[ps.Size] = ps
+	}
+
+	// Only two 5000 packs left, the other sizes are untracked
+	stock := 2
+	if _, err := packSizesService.SetPackSizeStock(bySize[5000].ID, &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 10000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.PackSetup != "2x5000" {
+		t.Errorf("expected pack setup 2x5000, got %q", order.PackSetup)
+	}
+	if order.Packs[0].PackSizeID != bySize[5000].ID {
+		t.Errorf("expected pack to reference pack size %v, got %v", bySize[5000].ID, order.Packs[0].PackSizeID)
+	}
+
+	// The catalog stored with the order doesn't keep the stock it was packed with
+	for _, ps := range order.Catalog {
+		if ps.Stock != nil {
+			t.Errorf("expected no stock in the order's catalog, got %d for size %d", *ps.Stock, ps.Size)
+		}
+	}
+
+	packSizes, err = packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusActive)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+	for _, ps := range packSizes {
+		if ps.Size == 5000 && (ps.Stock == nil || *ps.Stock != 0) {
+			t.Errorf("expected the 5000 pack to be out of stock, got %v", ps.Stock)
+		}
+	}
+
+	// The 5000 pack is out of stock now
+	order, err = service.CreateOrder(payload.CreateOrder{ItemsCount: 10000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.PackSetup != "5x2000" {
+		t.Errorf("expected pack setup 5x2000, got %q", order.PackSetup)
+	}
+
+	// Lines of the same order share the stock
+	stock = 1
+	if _, err := packSizesService.SetPackSizeStock(bySize[5000].ID, &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+	order, err = service.CreateOrder(payload.CreateOrder{
+		Lines: []payload.OrderLine{{ItemsCount: 5000}, {ItemsCount: 5000}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Lines[0].PackSetup != "1x5000" || order.Lines[1].PackSetup != "2x2000, 1x1000" {
+		t.Errorf("expected the second line to go without the 5000 pack, got %q and %q",
+			order.Lines[0].PackSetup, order.Lines[1].PackSetup)
+	}
+
+	// Nothing can be packed once every size runs out
+	for _, ps := range bySize {
+		noStock := 0
+		if _, err := packSizesService.SetPackSizeStock(ps.ID, &noStock); err != nil {
+			t.Fatalf("failed to set stock: %v", err)
+		}
+	}
+
+	ordersBefore := repo.Count()
+	if _, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 1}); !errors.Is(err, payload.ErrInsufficientStock) {
+		t.Errorf("expected ErrInsufficientStock, got %v", err)
+	}
+	if repo.Count() != ordersBefore {
+		t.Error("expected no order to be saved without stock")
+	}
+
+	negative := -1
+	if _, err := packSizesService.SetPackSizeStock(bySize[250].ID, &negative); !errors.Is(err, payload.ErrInvalidStock) {
+		t.Errorf("expected ErrInvalidStock, got %v", err)
+	}
+}
+
+func TestOrdersService_CreateOrderForProduct(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+	productsRepo := repositories.NewInMemoryProductsRepository()
+
+	product, err := NewProductsService(productsRepo).CreateProduct(models.Product{
+		ID:   uuid.New(),
+		Name: "Socks",
+		SKU:  "SOCKS-001",
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	for _, size := range []int{3, 10} {
+		if _, err := packSizesService.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: product.ID, Size: size}); err != nil {
+			t.Fatalf("failed to create pack size: %v", err)
+		}
+	}
+
+	service := NewOrdersService(repo, packSizesRepo, productsRepo, config.OrdersConfig{})
+
+	testCases := []struct {
+		name              string
+		productID         uuid.UUID
+		itemsCount        int
+		expectedProductID uuid.UUID
+		expectedPackSetup string
+	}{
+		{
+			name:              "Product pack sizes",
+			productID:         product.ID,
+			itemsCount:        12,
+			expectedProductID: product.ID,
+			expectedPackSetup: "4x3",
+		},
+		{
+			name:              "Default product when no product is given",
+			productID:         uuid.Nil,
+			itemsCount:        12,
+			expectedProductID: models.DefaultProductID,
+			expectedPackSetup: "1x250",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := service.CreateOrder(payload.CreateOrder{ProductID: tc.productID, ItemsCount: tc.itemsCount})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order.ProductID == nil || *order.ProductID != tc.expectedProductID {
+				t.Errorf("expected product %v, got %v", tc.expectedProductID, order.ProductID)
+			}
+			if order.PackSetup != tc.expectedPackSetup {
+				t.Errorf("expected pack setup %q, got %q", tc.expectedPackSetup, order.PackSetup)
+			}
+		})
+	}
+
+	_, err = service.CreateOrder(payload.CreateOrder{ProductID: uuid.New(), ItemsCount: 10})
+	if !errors.Is(err, payload.ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestOrdersService_CreateOrderWithoutPackSizes(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+	productsRepo := repositories.NewInMemoryProductsRepository()
+
+	product, err := NewProductsService(productsRepo).CreateProduct(models.Product{
+		ID:   uuid.New(),
+		Name: "Gloves",
+		SKU:  "GLOVES-001",
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	for _, strategy := range []string{StrategyFewestItems, StrategyFewestPacks, StrategyGreedy, StrategyCheapest} {
+		t.Run(strategy, func(t *testing.T) {
+			service := NewOrdersService(repo, packSizesRepo, productsRepo, config.OrdersConfig{})
+
+			_, err := service.CreateOrder(payload.CreateOrder{ProductID: product.ID, ItemsCount: 10, Strategy: strategy})
+			if !errors.Is(err, payload.ErrNoPackSizes) {
+				t.Errorf("expected ErrNoPackSizes, got %v", err)
+			}
+		})
+	}
+
+	if repo.Count() != 0 {
+		t.Errorf("expected no orders to be saved, got %d", repo.Count())
+	}
+}
+
+func TestOrdersService_CreateMultiLineOrder(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+	productsRepo := repositories.NewInMemoryProductsRepository()
+
+	product, err := NewProductsService(productsRepo).CreateProduct(models.Product{
+		ID:   uuid.New(),
+		Name: "Socks",
+		SKU:  "SOCKS-001",
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	for _, size := range []int{3, 10} {
+		if _, err := packSizesService.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: product.ID, Size: size}); err != nil {
+			t.Fatalf("failed to create pack size: %v", err)
+		}
+	}
+
+	service := NewOrdersService(repo, packSizesRepo, productsRepo, config.OrdersConfig{})
+
+	order, err := service.CreateOrder(payload.CreateOrder{
+		Lines: []payload.OrderLine{
+			{ProductID: models.DefaultProductID, ItemsCount: 501},
+			{ProductID: product.ID, ItemsCount: 13},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedLines := []struct {
+		productID        uuid.UUID
+		packSetup        string
+		shippedItems     int
+		totalPacks       int
+		catalogPackSizes int
+	}{
+		{productID: models.DefaultProductID, packSetup: "1x500, 1x250", shippedItems: 750, totalPacks: 2, catalogPackSizes: len(defaultPackSizes)},
+		{productID: product.ID, packSetup: "1x10, 1x3", shippedItems: 13, totalPacks: 2, catalogPackSizes: 2},
+	}
+
+	if len(order.Lines) != len(expectedLines) {
+		t.Fatalf("expected %d lines, got %d", len(expectedLines), len(order.Lines))
+	}
+
+	for i, expected := range expectedLines {
+		line := order.Lines[i]
+
+		if line.LineNumber != i+1 {
+			t.Errorf("line %d: expected line number %d, got %d", i, i+1, line.LineNumber)
+		}
+		if line.OrderID != order.ID {
+			t.Errorf("line %d: expected order ID %v, got %v", i, order.ID, line.OrderID)
+		}
+		if line.ProductID != expected.productID {
+			t.Errorf("line %d: expected product %v, got %v", i, expected.productID, line.ProductID)
+		}
+		if line.PackSetup != expected.packSetup {
+			t.Errorf("line %d: expected pack setup %q, got %q", i, expected.packSetup, line.PackSetup)
+		}
+		if line.ShippedItems != expected.shippedItems {
+			t.Errorf("line %d: expected %d shipped items, got %d", i, expected.shippedItems, line.ShippedItems)
+		}
+		if line.TotalPacks != expected.totalPacks {
+			t.Errorf("line %d: expected %d packs, got %d", i, expected.totalPacks, line.TotalPacks)
+		}
+		if len(line.Catalog) != expected.catalogPackSizes {
+			t.Errorf("line %d: expected %d pack sizes in the catalog, got %d", i, expected.catalogPackSizes, len(line.Catalog))
+		}
+	}
+
+	if order.ItemsCount != 514 || order.ShippedItems != 763 || order.Overshoot != 249 || order.TotalPacks != 4 {
+		t.Errorf("unexpected order totals: items %d, shipped %d, overshoot %d, packs %d",
+			order.ItemsCount, order.ShippedItems, order.Overshoot, order.TotalPacks)
+	}
+	if order.ProductID != nil || order.PackSetup != "" || len(order.Packs) != 0 || order.CatalogID != nil {
+		t.Errorf("expected no order level breakdown for a multi-line order, got %+v", order)
+	}
+
+	recalculation, err := service.RecalculateOrder(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recalculation.CatalogChanged || recalculation.PacksChanged {
+		t.Errorf("expected nothing to change, got %+v", recalculation)
+	}
+
+	// Unknown products in any line fail the whole order
+	_, err = service.CreateOrder(payload.CreateOrder{
+		Lines: []payload.OrderLine{
+			{ProductID: product.ID, ItemsCount: 5},
+			{ProductID: uuid.New(), ItemsCount: 5},
+		},
+	})
+	if !errors.Is(err, payload.ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+
+	// Lines can't be combined with the single product form
+	_, err = service.CreateOrder(payload.CreateOrder{
+		ItemsCount: 5,
+		Lines:      []payload.OrderLine{{ProductID: product.ID, ItemsCount: 5}},
+	})
+	if !errors.Is(err, payload.ErrAmbiguousOrderLines) {
+		t.Errorf("expected ErrAmbiguousOrderLines, got %v", err)
+	}
+
+	if repo.Count() != 1 {
+		t.Errorf("expected only the valid order to be saved, got %d orders", repo.Count())
+	}
+}
+
 func TestCalculatePackCombination(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -205,6 +964,7 @@ func TestCalculatePackCombination(t *testing.T) {
 		expectedPacks   map[int]int
 		expectedTotal   int
 		expectedMinimum bool // whether this should be the minimum items solution
+		expectedError   error
 	}{
 		{
 			name:            "Zero items",
@@ -247,11 +1007,77 @@ func TestCalculatePackCombination(t *testing.T) {
 			expectedTotal:   500000,
 			expectedMinimum: true,
 		},
+		{
+			name:            "500 million items",
+			itemsCount:      500000000,
+			packSizes:       defaultPackSizes,
+			expectedPacks:   map[int]int{5000: 100000},
+			expectedTotal:   500000000,
+			expectedMinimum: true,
+		},
+		{
+			name:            "500 million and 1 items",
+			itemsCount:      500000001,
+			packSizes:       defaultPackSizes,
+			expectedPacks:   map[int]int{5000: 100000, 250: 1},
+			expectedTotal:   500000250,
+			expectedMinimum: true,
+		},
+		{
+			name:            "1 billion items with custom pack sizes 23, 31, 53",
+			itemsCount:      1000000000,
+			packSizes:       []int{23, 31, 53},
+			expectedPacks:   map[int]int{23: 1, 31: 7, 53: 18867920},
+			expectedTotal:   1000000000,
+			expectedMinimum: true,
+		},
+		{
+			name:            "Unsorted pack sizes",
+			itemsCount:      12001,
+			packSizes:       []int{5000, 250, 2000, 500, 1000},
+			expectedPacks:   map[int]int{5000: 2, 2000: 1, 250: 1},
+			expectedTotal:   12250,
+			expectedMinimum: true,
+		},
+		{
+			name:            "Duplicate and non-positive pack sizes are ignored",
+			itemsCount:      501,
+			packSizes:       []int{500, 0, 250, -250, 500},
+			expectedPacks:   map[int]int{500: 1, 250: 1},
+			expectedTotal:   750,
+			expectedMinimum: true,
+		},
+		{
+			name:          "No pack sizes",
+			itemsCount:    1,
+			packSizes:     []int{},
+			expectedError: payload.ErrNoPackSizes,
+		},
+		{
+			name:          "Only non-positive pack sizes",
+			itemsCount:    1,
+			packSizes:     []int{0, -5},
+			expectedError: payload.ErrNoPackSizes,
+		},
+		{
+			name:            "No pack sizes and nothing to pack",
+			itemsCount:      0,
+			packSizes:       nil,
+			expectedPacks:   map[int]int{},
+			expectedTotal:   0,
+			expectedMinimum: true,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := calculatePackCombination(tc.itemsCount, tc.packSizes)
+			result, err := calculatePackCombination(tc.itemsCount, tc.packSizes)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
 
 			// Check pack counts match
 			if len(result.Packs) != len(tc.expectedPacks) {
@@ -284,13 +1110,151 @@ func TestCalculatePackCombination(t *testing.T) {
 	}
 }
 
+func BenchmarkCalculatePackCombination(b *testing.B) {
+	benchmarks := []struct {
+		name       string
+		itemsCount int
+		packSizes  []int
+	}{
+		{name: "12001 items", itemsCount: 12001, packSizes: defaultPackSizes},
+		{name: "500 million items", itemsCount: 500000000, packSizes: defaultPackSizes},
+		{name: "500000 items with custom pack sizes", itemsCount: 500000, packSizes: []int{23, 31, 53}},
+		{name: "500 million items with custom pack sizes", itemsCount: 500000000, packSizes: []int{23, 31, 53}},
+		{name: "500 million items with large coprime pack sizes", itemsCount: 500000000, packSizes: []int{9973, 10007, 49999}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = calculatePackCombination(bm.itemsCount, bm.packSizes)
+			}
+		})
+	}
+}
+
+func TestCalculatePackCombinationWithStock(t *testing.T) {
+	testCases := []struct {
+		name          string
+		itemsCount    int
+		packSizes     []int
+		stock         map[int]int
+		expectedPacks map[int]int
+		expectedError error
+	}{
+		{
+			name:          "Untracked stock matches the unbounded solver",
+			itemsCount:    501,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{},
+			expectedPacks: map[int]int{500: 1, 250: 1},
+		},
+		{
+			name:          "Out of the largest pack",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 0},
+			expectedPacks: map[int]int{2000: 6, 250: 1},
+		},
+		{
+			name:          "Only one of the largest pack left",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 1},
+			expectedPacks: map[int]int{5000: 1, 2000: 3, 1000: 1, 250: 1},
+		},
+		{
+			name:          "Limited small pack costs extra items",
+			itemsCount:    11,
+			packSizes:     []int{3, 5},
+			stock:         map[int]int{3: 1},
+			expectedPacks: map[int]int{3: 1, 5: 2},
+		},
+		{
+			name:          "Stock covers the order exactly",
+			itemsCount:    13,
+			packSizes:     []int{3, 10},
+			stock:         map[int]int{3: 5, 10: 0},
+			expectedPacks: map[int]int{3: 5},
+		},
+		{
+			name:          "Not enough packs in stock",
+			itemsCount:    251,
+			packSizes:     []int{250},
+			stock:         map[int]int{250: 1},
+			expectedError: payload.ErrInsufficientStock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := calculatePackCombinationWithStock(tc.itemsCount, tc.packSizes, tc.stock)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(result.Packs) != len(tc.expectedPacks) {
+				t.Errorf("expected packs %v, got %v", tc.expectedPacks, result.Packs)
+			}
+			for packSize, expectedCount := range tc.expectedPacks {
+				if result.Packs[packSize] != expectedCount {
+					t.Errorf("pack size %d: expected %d, got %d", packSize, expectedCount, result.Packs[packSize])
+				}
+			}
+
+			for packSize, count := range result.Packs {
+				if available, tracked := tc.stock[packSize]; tracked && count > available {
+					t.Errorf("pack size %d: used %d packs with only %d in stock", packSize, count, available)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatPackSetup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		packs    map[int]int
+		expected string
+	}{
+		{
+			name:     "No packs",
+			packs:    map[int]int{},
+			expected: "",
+		},
+		{
+			name:     "Single pack size",
+			packs:    map[int]int{500: 2},
+			expected: "2x500",
+		},
+		{
+			name:     "Multiple pack sizes are ordered from largest to smallest",
+			packs:    map[int]int{250: 1, 5000: 2, 2000: 1},
+			expected: "2x5000, 1x2000, 1x250",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatPackSetup(formatPacks(tc.packs, nil)); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
 func setupPackSizesRepositoryWithDefaults() *repositories.InMemoryPackSizesRepository {
 	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
 	defaultPackSizes := []int{250, 500, 1000, 2000, 5000}
 	for _, size := range defaultPackSizes {
-		_, _ = repo.CreatePackSize(models.PackSize{
-			ID:   uuid.New(),
-			Size: size,
+		_, _ = service.CreatePackSize(models.PackSize{
+			ID:        uuid.New(),
+			ProductID: models.DefaultProductID,
+			Size:      size,
 		})
 	}
 
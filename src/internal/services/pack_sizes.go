@@ -1,15 +1,34 @@
 package services
 
-import "github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
 
 type PackSizeRepository interface {
-	GetAllPackSizes() ([]models.PackSize, error)
-	CreatePackSize(packSize models.PackSize) (models.PackSize, error)
-	UpdatePackSize(packSize models.PackSize) (models.PackSize, error)
+	GetAllPackSizes(productID string) ([]models.PackSize, error)
+	GetPackSize(packSizeID string) (models.PackSize, error)
+	SetPackSizeStock(packSizeID string, stock *int) (models.PackSize, error)
+	GetAllCatalogs(productID string) ([]models.PackSizeCatalog, error)
+	GetCatalogAt(productID string, at time.Time) (models.PackSizeCatalog, error)
+	ChangePackSizes(productID uuid.UUID, change func(models.ProductPackSizes) (models.PackSizeChanges, error)) (models.PackSizeChanges, error)
 }
 
+// maxCatalogSizes caps the pack sizes a catalog is replaced with.
+const maxCatalogSizes = 100
+
 type PackSizesService struct {
-	repo PackSizeRepository
+	repo      PackSizeRepository
+	listeners []func(productID uuid.UUID)
+	announce  func(productID uuid.UUID) models.CatalogChange
 }
 
 func NewPackSizesService(repo PackSizeRepository) *PackSizesService {
@@ -18,29 +37,467 @@ func NewPackSizesService(repo PackSizeRepository) *PackSizesService {
 	}
 }
 
-func (s *PackSizesService) GetAllPackSizes() ([]models.PackSize, error) {
-	packSizes, err := s.repo.GetAllPackSizes()
+// OnChange registers a listener called with the product whenever one of its
+// pack sizes is created, updated or deleted or a catalog version of it is
+// scheduled. Listeners are meant to be registered at startup, before the
+// service is used.
+func (s *PackSizesService) OnChange(listener func(productID uuid.UUID)) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// AnnounceChanges sets the change sent to the other replicas whenever a
+// catalog version of a product is published or rewritten. It's sent in the
+// same transaction as the change, so they only hear about saved changes.
+// Like listeners, it's meant to be set at startup.
+func (s *PackSizesService) AnnounceChanges(announce func(productID uuid.UUID) models.CatalogChange) {
+	s.announce = announce
+}
+
+func (s *PackSizesService) notifyChange(productID uuid.UUID) {
+	for _, listener := range s.listeners {
+		listener(productID)
+	}
+}
+
+// GetAllPackSizes returns the product's pack sizes with the given status.
+func (s *PackSizesService) GetAllPackSizes(productID uuid.UUID, status payload.PackSizeStatus) ([]models.PackSize, error) {
+	if status != payload.PackSizeStatusActive && status != payload.PackSizeStatusInactive && status != payload.PackSizeStatusAll {
+		return nil, payload.ErrInvalidPackSizeStatus
+	}
+
+	packSizes, err := s.repo.GetAllPackSizes(productID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if status == payload.PackSizeStatusAll {
+		return packSizes, nil
+	}
+
+	filtered := make([]models.PackSize, 0, len(packSizes))
+	for _, ps := range packSizes {
+		if ps.Active == (status == payload.PackSizeStatusActive) {
+			filtered = append(filtered, ps)
+		}
+	}
+
+	return filtered, nil
+}
+
+// GetPackSizesAt returns the pack sizes of the product's catalog version
+// that was effective at the given time.
+func (s *PackSizesService) GetPackSizesAt(productID uuid.UUID, at time.Time) ([]models.PackSize, error) {
+	catalog, err := getCatalogAt(s.repo, productID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.PackSizes, nil
+}
+
+func (s *PackSizesService) GetAllCatalogs(productID uuid.UUID) ([]models.PackSizeCatalog, error) {
+	catalogs, err := s.repo.GetAllCatalogs(productID.String())
 	if err != nil {
 		return nil, err
 	}
 
-	return packSizes, nil
+	return catalogs, nil
 }
 
 func (s *PackSizesService) CreatePackSize(packSize models.PackSize) (models.PackSize, error) {
-	createdPackSize, err := s.repo.CreatePackSize(packSize)
+	if err := validatePackSize(packSize); err != nil {
+		return models.PackSize{}, err
+	}
+
+	// New pack sizes start active, like the column default
+	packSize.Active = true
+
+	saved, err := s.changePackSizes(packSize.ProductID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		return publishChanges(packSize.ProductID, state, models.PackSizeChanges{Created: []models.PackSize{packSize}}), nil
+	})
 	if err != nil {
 		return models.PackSize{}, err
 	}
 
-	return createdPackSize, nil
+	return saved.Created[0], nil
 }
 
-func (s *PackSizesService) UpdatePackSize(packSize models.PackSize) (models.PackSize, error) {
-	updatedPackSize, err := s.repo.UpdatePackSize(packSize)
+// UpdatePackSize changes the size, the active flag and/or the unit cost of
+// a pack size, leaving out whichever isn't given, and publishes a single
+// catalog version with all of them. Deactivated sizes are left out of the
+// catalog until they're activated again, and orders keep the price they
+// were quoted with.
+func (s *PackSizesService) UpdatePackSize(update payload.UpdatePackSize) (models.PackSize, error) {
+	if update.Size < 0 {
+		return models.PackSize{}, payload.ErrInvalidPackSize
+	}
+	if update.UnitCostCents != nil && *update.UnitCostCents < 0 {
+		return models.PackSize{}, payload.ErrInvalidCost
+	}
+
+	return s.updatePackSize(update.ID, func(existing models.PackSize) models.PackSize {
+		if update.Size > 0 {
+			existing.Size = update.Size
+		}
+		if update.Active != nil {
+			existing.Active = *update.Active
+		}
+		if update.UnitCostCents != nil {
+			existing.UnitCostCents = *update.UnitCostCents
+		}
+
+		return existing
+	})
+}
+
+// SetPackSizeStock sets the packs of a size in stock, or stops tracking its
+// stock when nil. Stock isn't part of the catalog so no new version is
+// published.
+func (s *PackSizesService) SetPackSizeStock(packSizeID uuid.UUID, stock *int) (models.PackSize, error) {
+	if stock != nil && *stock < 0 {
+		return models.PackSize{}, payload.ErrInvalidStock
+	}
+
+	packSize, err := s.repo.SetPackSizeStock(packSizeID.String(), stock)
 	if err != nil {
+		return models.PackSize{}, packSizeNotFoundError(err)
+	}
+
+	return packSize, nil
+}
+
+// SetPackSizeDimensions changes the weight and outer dimensions of a single
+// pack. Like prices they're part of the catalog, so a new version is
+// published.
+func (s *PackSizesService) SetPackSizeDimensions(packSize models.PackSize) (models.PackSize, error) {
+	if err := validateDimensions(packSize); err != nil {
 		return models.PackSize{}, err
 	}
 
-	return updatedPackSize, nil
+	return s.updatePackSize(packSize.ID, func(existing models.PackSize) models.PackSize {
+		existing.WeightGrams = packSize.WeightGrams
+		existing.LengthMm = packSize.LengthMm
+		existing.WidthMm = packSize.WidthMm
+		existing.HeightMm = packSize.HeightMm
+		return existing
+	})
+}
+
+// DeletePackSize removes a pack size for good. Catalog versions and orders
+// that used it keep their own snapshot of it.
+func (s *PackSizesService) DeletePackSize(packSizeID uuid.UUID) error {
+	_, err := s.changePackSize(packSizeID, func(existing models.PackSize) models.PackSizeChanges {
+		return models.PackSizeChanges{Deleted: []uuid.UUID{existing.ID}}
+	})
+
+	return err
+}
+
+// ScheduleCatalog creates a catalog version of the product with the given
+// sizes that becomes effective at effectiveFrom. Sizes that don't exist yet
+// are created, in the same transaction as the catalog, so they can be
+// managed like any other pack size. Inactive sizes are refused rather than
+// scheduled behind the back of whoever deactivated them.
+func (s *PackSizesService) ScheduleCatalog(productID uuid.UUID, effectiveFrom time.Time, sizes []int) (models.PackSizeCatalog, error) {
+	if effectiveFrom.Before(time.Now()) {
+		return models.PackSizeCatalog{}, payload.ErrCatalogEffectiveFromPast
+	}
+
+	saved, err := s.changePackSizes(productID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		bySize := make(map[int]models.PackSize, len(state.PackSizes))
+		for _, ps := range state.PackSizes {
+			bySize[ps.Size] = ps
+		}
+
+		var changes models.PackSizeChanges
+		packSizes := make([]models.PackSize, 0, len(sizes))
+		for _, size := range sizes {
+			packSize, exists := bySize[size]
+			if !exists {
+				packSize = models.PackSize{ID: uuid.New(), ProductID: productID, Size: size, Active: true}
+				changes.Created = append(changes.Created, packSize)
+				bySize[size] = packSize
+			}
+
+			if !packSize.Active {
+				return models.PackSizeChanges{}, payload.ErrInactivePackSize
+			}
+
+			packSizes = append(packSizes, packSize)
+		}
+
+		changes.Catalogs = []models.PackSizeCatalog{{
+			ID:            uuid.New(),
+			ProductID:     productID,
+			EffectiveFrom: effectiveFrom,
+			PackSizes:     snapshotCatalog(packSizes),
+		}}
+
+		return changes, nil
+	})
+	if err != nil {
+		return models.PackSizeCatalog{}, err
+	}
+
+	return saved.Catalogs[0], nil
+}
+
+// ReplacePackSizes replaces every pack size of the product with the given
+// sizes and publishes the catalog made of them, effective immediately, all
+// in a single transaction so orders never see a half replaced catalog. Sizes
+// the product already has keep their stock, cost and dimensions and are
+// activated again if they were inactive, and the others are deleted.
+func (s *PackSizesService) ReplacePackSizes(productID uuid.UUID, sizes []int) (models.PackSizeCatalog, error) {
+	if len(sizes) == 0 {
+		return models.PackSizeCatalog{}, payload.ErrNoSizes
+	}
+	if len(sizes) > maxCatalogSizes {
+		return models.PackSizeCatalog{}, payload.ErrTooManySizes
+	}
+
+	requested := make(map[int]bool, len(sizes))
+	for _, size := range sizes {
+		if size <= 0 {
+			return models.PackSizeCatalog{}, payload.ErrInvalidPackSize
+		}
+		if requested[size] {
+			return models.PackSizeCatalog{}, payload.ErrDuplicateSize
+		}
+
+		requested[size] = true
+	}
+
+	saved, err := s.changePackSizes(productID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		var changes models.PackSizeChanges
+		for _, ps := range state.PackSizes {
+			if !requested[ps.Size] {
+				changes.Deleted = append(changes.Deleted, ps.ID)
+				continue
+			}
+
+			if !ps.Active {
+				ps.Active = true
+				changes.Updated = append(changes.Updated, ps)
+			}
+
+			delete(requested, ps.Size)
+		}
+
+		for _, size := range sizes {
+			if requested[size] {
+				changes.Created = append(changes.Created, models.PackSize{ID: uuid.New(), ProductID: productID, Size: size, Active: true})
+			}
+		}
+
+		return publishChanges(productID, state, changes), nil
+	})
+	if err != nil {
+		return models.PackSizeCatalog{}, err
+	}
+
+	return saved.Catalogs[0], nil
+}
+
+// changePackSizes saves the changes made from the current state of the
+// product's pack sizes, one change of the product at a time, and lets the
+// listeners know when a catalog version was published or rewritten.
+func (s *PackSizesService) changePackSizes(productID uuid.UUID, change func(models.ProductPackSizes) (models.PackSizeChanges, error)) (models.PackSizeChanges, error) {
+	saved, err := s.repo.ChangePackSizes(productID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		changes, err := change(state)
+		if err != nil {
+			return models.PackSizeChanges{}, err
+		}
+
+		if s.announce != nil && (len(changes.Catalogs) > 0 || len(changes.Pending) > 0) {
+			announcement := s.announce(productID)
+			changes.Notification = &announcement
+		}
+
+		return changes, nil
+	})
+	if err != nil {
+		// Nothing to lock means there's no such product
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.PackSizeChanges{}, payload.ErrProductNotFound
+		}
+
+		return models.PackSizeChanges{}, productReferenceError(err)
+	}
+
+	if len(saved.Catalogs) > 0 || len(saved.Pending) > 0 {
+		s.notifyChange(productID)
+	}
+
+	return saved, nil
+}
+
+// changePackSize makes and publishes a change of a single pack size, as it
+// is once its product is locked.
+func (s *PackSizesService) changePackSize(packSizeID uuid.UUID, change func(models.PackSize) models.PackSizeChanges) (models.PackSizeChanges, error) {
+	packSize, err := s.repo.GetPackSize(packSizeID.String())
+	if err != nil {
+		return models.PackSizeChanges{}, packSizeNotFoundError(err)
+	}
+
+	saved, err := s.changePackSizes(packSize.ProductID, func(state models.ProductPackSizes) (models.PackSizeChanges, error) {
+		index := slices.IndexFunc(state.PackSizes, func(ps models.PackSize) bool {
+			return ps.ID == packSizeID
+		})
+		if index < 0 {
+			return models.PackSizeChanges{}, payload.ErrPackSizeNotFound
+		}
+
+		return publishChanges(packSize.ProductID, state, change(state.PackSizes[index])), nil
+	})
+	if errors.Is(err, payload.ErrProductNotFound) {
+		// The pack size was deleted along with its product
+		return models.PackSizeChanges{}, payload.ErrPackSizeNotFound
+	}
+
+	return saved, err
+}
+
+// updatePackSize updates and publishes a single pack size.
+func (s *PackSizesService) updatePackSize(packSizeID uuid.UUID, update func(models.PackSize) models.PackSize) (models.PackSize, error) {
+	saved, err := s.changePackSize(packSizeID, func(existing models.PackSize) models.PackSizeChanges {
+		return models.PackSizeChanges{Updated: []models.PackSize{update(existing)}}
+	})
+	if err != nil {
+		return models.PackSize{}, err
+	}
+
+	return saved.Updated[0], nil
+}
+
+// publishChanges adds to the changes a catalog version effective
+// immediately, made from the current one without the deleted pack sizes
+// and with the updated and created ones in place of their previous
+// versions. Scheduled versions are rewritten the same way, so they don't
+// bring back what the change undid once they become effective.
+func publishChanges(productID uuid.UUID, state models.ProductPackSizes, changes models.PackSizeChanges) models.PackSizeChanges {
+	apply := func(packSizes []models.PackSize) []models.PackSize {
+		packSizes = slices.Clone(packSizes)
+		for _, packSizeID := range changes.Deleted {
+			packSizes = removePackSize(packSizeID)(packSizes)
+		}
+		for _, packSize := range slices.Concat(changes.Updated, changes.Created) {
+			packSizes = replacePackSize(packSize)(packSizes)
+		}
+
+		return snapshotCatalog(packSizes)
+	}
+
+	current := []models.PackSize{}
+	if state.Current != nil {
+		current = state.Current.PackSizes
+	}
+
+	changes.Catalogs = append(changes.Catalogs, models.PackSizeCatalog{
+		ID:            uuid.New(),
+		ProductID:     productID,
+		EffectiveFrom: state.At,
+		PackSizes:     apply(current),
+	})
+
+	for _, pending := range state.Pending {
+		pending.PackSizes = apply(pending.PackSizes)
+		changes.Pending = append(changes.Pending, pending)
+	}
+
+	return changes
+}
+
+// replacePackSize puts the given pack size in place of its previous version
+// in the catalog, or drops it when it's inactive.
+func replacePackSize(packSize models.PackSize) func([]models.PackSize) []models.PackSize {
+	return func(packSizes []models.PackSize) []models.PackSize {
+		packSizes = removePackSize(packSize.ID)(packSizes)
+		if !packSize.Active {
+			return packSizes
+		}
+
+		return append(packSizes, packSize)
+	}
+}
+
+func removePackSize(packSizeID uuid.UUID) func([]models.PackSize) []models.PackSize {
+	return func(packSizes []models.PackSize) []models.PackSize {
+		result := make([]models.PackSize, 0, len(packSizes))
+		for _, ps := range packSizes {
+			if ps.ID != packSizeID {
+				result = append(result, ps)
+			}
+		}
+
+		return result
+	}
+}
+
+// getCatalogAt returns the product's catalog effective at the given time,
+// or an empty catalog when none was published yet.
+func getCatalogAt(repo PackSizeRepository, productID uuid.UUID, at time.Time) (models.PackSizeCatalog, error) {
+	catalog, err := repo.GetCatalogAt(productID.String(), at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.PackSizeCatalog{ProductID: productID, PackSizes: []models.PackSize{}}, nil
+		}
+
+		return models.PackSizeCatalog{}, err
+	}
+
+	return catalog, nil
+}
+
+// validatePackSize checks a new pack size against the rules of
+// payload.CreatePackSize.
+func validatePackSize(packSize models.PackSize) error {
+	if packSize.Size <= 0 {
+		return payload.ErrInvalidPackSize
+	}
+
+	if packSize.Stock != nil && *packSize.Stock < 0 {
+		return payload.ErrInvalidStock
+	}
+
+	if packSize.UnitCostCents < 0 {
+		return payload.ErrInvalidCost
+	}
+
+	return validateDimensions(packSize)
+}
+
+// validateDimensions checks the weight and outer dimensions of a pack, with
+// an error of its own for each of them.
+func validateDimensions(packSize models.PackSize) error {
+	switch {
+	case packSize.WeightGrams < 0:
+		return payload.ErrInvalidWeight
+	case packSize.LengthMm < 0:
+		return payload.ErrInvalidLength
+	case packSize.WidthMm < 0:
+		return payload.ErrInvalidWidth
+	case packSize.HeightMm < 0:
+		return payload.ErrInvalidHeight
+	}
+
+	return nil
+}
+
+func packSizeNotFoundError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+		return payload.ErrPackSizeNotFound
+	}
+
+	return err
+}
+
+// productReferenceError reports writes pointing at a product that doesn't
+// exist as ErrProductNotFound.
+func productReferenceError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return payload.ErrProductNotFound
+	}
+
+	return err
 }
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"errors"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
 )
 
 func TestPackSizesService_GetAllPackSizes(t *testing.T) {
@@ -13,7 +18,7 @@ func TestPackSizesService_GetAllPackSizes(t *testing.T) {
 	service := NewPackSizesService(repo)
 
 	// Initially should be empty
-	packSizes, err := service.GetAllPackSizes()
+	packSizes, err := service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -25,8 +30,9 @@ func TestPackSizesService_GetAllPackSizes(t *testing.T) {
 	sizes := []int{250, 500, 1000}
 	for _, size := range sizes {
 		_, err := repo.CreatePackSize(models.PackSize{
-			ID:   uuid.New(),
-			Size: size,
+			ID:        uuid.New(),
+			ProductID: models.DefaultProductID,
+			Size:      size,
 		})
 		if err != nil {
 			t.Fatalf("failed to create pack size: %v", err)
@@ -34,7 +40,7 @@ func TestPackSizesService_GetAllPackSizes(t *testing.T) {
 	}
 
 	// Should now have 3 pack sizes
-	packSizes, err = service.GetAllPackSizes()
+	packSizes, err = service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -62,29 +68,33 @@ func TestPackSizesService_CreatePackSize(t *testing.T) {
 		{
 			name: "Create pack size 250",
 			packSize: models.PackSize{
-				ID:   uuid.New(),
-				Size: 250,
+				ID:        uuid.New(),
+				ProductID: models.DefaultProductID,
+				Size:      250,
 			},
 		},
 		{
 			name: "Create pack size 500",
 			packSize: models.PackSize{
-				ID:   uuid.New(),
-				Size: 500,
+				ID:        uuid.New(),
+				ProductID: models.DefaultProductID,
+				Size:      500,
 			},
 		},
 		{
 			name: "Create pack size 1000",
 			packSize: models.PackSize{
-				ID:   uuid.New(),
-				Size: 1000,
+				ID:        uuid.New(),
+				ProductID: models.DefaultProductID,
+				Size:      1000,
 			},
 		},
 		{
 			name: "Create pack size with large value",
 			packSize: models.PackSize{
-				ID:   uuid.New(),
-				Size: 10000,
+				ID:        uuid.New(),
+				ProductID: models.DefaultProductID,
+				Size:      10000,
 			},
 		},
 	}
@@ -108,7 +118,7 @@ func TestPackSizesService_CreatePackSize(t *testing.T) {
 			}
 
 			// Verify it was actually saved in the repository
-			allPackSizes, err := repo.GetAllPackSizes()
+			allPackSizes, err := repo.GetAllPackSizes(models.DefaultProductID.String())
 			if err != nil {
 				t.Fatalf("failed to get all pack sizes: %v", err)
 			}
@@ -133,8 +143,9 @@ func TestPackSizesService_UpdatePackSize(t *testing.T) {
 
 	// Create initial pack size
 	initialPackSize := models.PackSize{
-		ID:   uuid.New(),
-		Size: 250,
+		ID:        uuid.New(),
+		ProductID: models.DefaultProductID,
+		Size:      250,
 	}
 	_, err := repo.CreatePackSize(initialPackSize)
 	if err != nil {
@@ -142,7 +153,7 @@ func TestPackSizesService_UpdatePackSize(t *testing.T) {
 	}
 
 	// Update the pack size
-	updatedPackSize := models.PackSize{
+	updatedPackSize := payload.UpdatePackSize{
 		ID:   initialPackSize.ID,
 		Size: 500,
 	}
@@ -160,7 +171,7 @@ func TestPackSizesService_UpdatePackSize(t *testing.T) {
 	}
 
 	// Verify it was actually updated in the repository
-	allPackSizes, err := repo.GetAllPackSizes()
+	allPackSizes, err := repo.GetAllPackSizes(models.DefaultProductID.String())
 	if err != nil {
 		t.Fatalf("failed to get all pack sizes: %v", err)
 	}
@@ -180,6 +191,52 @@ func TestPackSizesService_UpdatePackSize(t *testing.T) {
 	}
 }
 
+func TestPackSizesService_UpdatePackSizePublishesOneVersion(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	created, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 250})
+	if err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+
+	inactive := false
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created.ID, Active: &inactive}); err != nil {
+		t.Fatalf("failed to deactivate pack size: %v", err)
+	}
+
+	// The size, the active flag and the cost change together
+	active, cost := true, 120
+	updated, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created.ID, Size: 300, Active: &active, UnitCostCents: &cost})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Size != 300 || !updated.Active || updated.UnitCostCents != 120 {
+		t.Errorf("expected an active 300 pack costing 120, got %+v", updated)
+	}
+
+	catalogs, err := service.GetAllCatalogs(models.DefaultProductID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalogs) != 3 {
+		t.Fatalf("expected a catalog version per update, got %d", len(catalogs))
+	}
+
+	packSizes, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(packSizes, []models.PackSize{updated}) {
+		t.Errorf("expected the catalog to hold the updated pack size, got %v", packSizes)
+	}
+
+	cost = -1
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created.ID, UnitCostCents: &cost}); !errors.Is(err, payload.ErrInvalidCost) {
+		t.Errorf("expected ErrInvalidCost, got %v", err)
+	}
+}
+
 func TestPackSizesService_CreateMultiplePackSizes(t *testing.T) {
 	repo := repositories.NewInMemoryPackSizesRepository()
 	service := NewPackSizesService(repo)
@@ -190,8 +247,9 @@ func TestPackSizesService_CreateMultiplePackSizes(t *testing.T) {
 	// Create multiple pack sizes
 	for _, size := range sizes {
 		packSize := models.PackSize{
-			ID:   uuid.New(),
-			Size: size,
+			ID:        uuid.New(),
+			ProductID: models.DefaultProductID,
+			Size:      size,
 		}
 		created, err := service.CreatePackSize(packSize)
 		if err != nil {
@@ -201,7 +259,7 @@ func TestPackSizesService_CreateMultiplePackSizes(t *testing.T) {
 	}
 
 	// Verify all were created
-	allPackSizes, err := service.GetAllPackSizes()
+	allPackSizes, err := service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -236,36 +294,437 @@ func TestPackSizesService_UpdateNonExistentPackSize(t *testing.T) {
 	service := NewPackSizesService(repo)
 
 	// Try to update a pack size that doesn't exist
-	nonExistentPackSize := models.PackSize{
+	nonExistentPackSize := payload.UpdatePackSize{
 		ID:   uuid.New(),
 		Size: 999,
 	}
 
-	// This should still work as the in-memory repository doesn't validate existence
-	// It will just create a new entry
-	result, err := service.UpdatePackSize(nonExistentPackSize)
+	_, err := service.UpdatePackSize(nonExistentPackSize)
+	if !errors.Is(err, payload.ErrPackSizeNotFound) {
+		t.Fatalf("expected ErrPackSizeNotFound, got %v", err)
+	}
+
+	// Nothing is created by the update
+	allPackSizes, err := service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get all pack sizes: %v", err)
+	}
+	if len(allPackSizes) != 0 {
+		t.Errorf("expected no pack sizes, got %d", len(allPackSizes))
+	}
+}
+
+func TestPackSizesService_PublishesCatalogVersions(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	// No catalog was published yet
+	packSizes, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if len(packSizes) != 0 {
+		t.Errorf("expected empty catalog, got %d pack sizes", len(packSizes))
+	}
+
+	created, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 250})
+	if err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+	if _, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 500}); err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+
+	beforeUpdate := time.Now()
+	time.Sleep(time.Millisecond)
 
-	if result.ID != nonExistentPackSize.ID {
-		t.Errorf("expected ID %v, got %v", nonExistentPackSize.ID, result.ID)
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created.ID, Size: 300}); err != nil {
+		t.Fatalf("failed to update pack size: %v", err)
 	}
 
-	// Verify it exists now
-	allPackSizes, err := service.GetAllPackSizes()
+	catalogs, err := service.GetAllCatalogs(models.DefaultProductID)
 	if err != nil {
-		t.Fatalf("failed to get all pack sizes: %v", err)
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalogs) != 3 {
+		t.Fatalf("expected a catalog version per change, got %d", len(catalogs))
 	}
 
-	found := false
+	testCases := []struct {
+		name          string
+		at            time.Time
+		expectedSizes []int
+	}{
+		{
+			name:          "Before the update",
+			at:            beforeUpdate,
+			expectedSizes: []int{250, 500},
+		},
+		{
+			name:          "After the update",
+			at:            time.Now(),
+			expectedSizes: []int{300, 500},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packSizes, err := service.GetPackSizesAt(models.DefaultProductID, tc.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(packSizes) != len(tc.expectedSizes) {
+				t.Fatalf("expected %d pack sizes, got %d", len(tc.expectedSizes), len(packSizes))
+			}
+			for i, ps := range packSizes {
+				if ps.Size != tc.expectedSizes[i] {
+					t.Errorf("expected size %d at position %d, got %d", tc.expectedSizes[i], i, ps.Size)
+				}
+			}
+		})
+	}
+}
+
+func TestPackSizesService_ScheduleCatalog(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	existing, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 500})
+	if err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+
+	effectiveFrom := time.Now().Add(7 * 24 * time.Hour)
+	catalog, err := service.ScheduleCatalog(models.DefaultProductID, effectiveFrom, []int{750, 500})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(catalog.PackSizes) != 2 || catalog.PackSizes[0].Size != 500 || catalog.PackSizes[1].Size != 750 {
+		t.Fatalf("expected scheduled sizes [500 750], got %v", catalog.PackSizes)
+	}
+	if catalog.PackSizes[0].ID != existing.ID {
+		t.Errorf("expected existing pack size %v to be reused, got %v", existing.ID, catalog.PackSizes[0].ID)
+	}
+
+	// The scheduled catalog isn't effective yet
+	current, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current) != 1 {
+		t.Errorf("expected the current catalog to be unchanged, got %v", current)
+	}
+
+	scheduled, err := service.GetPackSizesAt(models.DefaultProductID, effectiveFrom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scheduled) != 2 {
+		t.Errorf("expected the scheduled catalog at its effective date, got %v", scheduled)
+	}
+
+	// New sizes are registered as pack sizes
+	allPackSizes, err := service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(allPackSizes) != 2 {
+		t.Errorf("expected 2 pack sizes, got %d", len(allPackSizes))
+	}
+
+	// Catalogs can't be scheduled in the past
+	_, err = service.ScheduleCatalog(models.DefaultProductID, time.Now().Add(-time.Hour), []int{1000})
+	if !errors.Is(err, payload.ErrCatalogEffectiveFromPast) {
+		t.Errorf("expected ErrCatalogEffectiveFromPast, got %v", err)
+	}
+
+	// Nor with inactive sizes, and nothing is created when they're refused
+	inactive := false
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: existing.ID, Active: &inactive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = service.ScheduleCatalog(models.DefaultProductID, effectiveFrom, []int{2000, 500})
+	if !errors.Is(err, payload.ErrInactivePackSize) {
+		t.Errorf("expected ErrInactivePackSize, got %v", err)
+	}
+
+	allPackSizes, err = service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(allPackSizes) != 2 {
+		t.Errorf("expected 2 pack sizes, got %d", len(allPackSizes))
+	}
+}
+
+func TestPackSizesService_ChangesRewriteScheduledCatalogs(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	created := make(map[int]models.PackSize)
+	for _, size := range []int{250, 500, 1000} {
+		packSize, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: size})
+		if err != nil {
+			t.Fatalf("failed to create pack size %d: %v", size, err)
+		}
+		created[size] = packSize
+	}
+
+	effectiveFrom := time.Now().Add(24 * time.Hour)
+	if _, err := service.ScheduleCatalog(models.DefaultProductID, effectiveFrom, []int{250, 500, 1000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Changes made before the scheduled catalog is effective are kept by it
+	inactive, cost := false, 99
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created[500].ID, Active: &inactive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created[250].ID, UnitCostCents: &cost}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.DeletePackSize(created[1000].ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 2000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, at := range []time.Time{time.Now(), effectiveFrom} {
+		packSizes, err := service.GetPackSizesAt(models.DefaultProductID, at)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(packSizes) != 2 || packSizes[0].Size != 250 || packSizes[1].Size != 2000 {
+			t.Fatalf("expected sizes [250 2000] at %v, got %v", at, packSizes)
+		}
+		if packSizes[0].UnitCostCents != 99 {
+			t.Errorf("expected the new cost at %v, got %d", at, packSizes[0].UnitCostCents)
+		}
+	}
+}
+
+func TestPackSizesService_ConcurrentChanges(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	const count = 20
+
+	var wg sync.WaitGroup
+	for size := 1; size <= count; size++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: size}); err != nil {
+				t.Errorf("failed to create pack size %d: %v", size, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every catalog version is made from the previous one, so none is lost
+	packSizes, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(packSizes) != count {
+		t.Errorf("expected %d pack sizes in the catalog, got %d", count, len(packSizes))
+	}
+}
+
+func TestPackSizesService_DeactivateAndDeletePackSize(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	created := make(map[int]models.PackSize)
+	for _, size := range []int{250, 500, 1000} {
+		packSize, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: size})
+		if err != nil {
+			t.Fatalf("failed to create pack size %d: %v", size, err)
+		}
+		created[size] = packSize
+	}
+
+	active := false
+	deactivated, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created[500].ID, Active: &active})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deactivated.Active {
+		t.Error("expected pack size to be inactive")
+	}
+
+	if err := service.DeletePackSize(created[1000].ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		status        payload.PackSizeStatus
+		expectedSizes []int
+		expectedError error
+	}{
+		{status: payload.PackSizeStatusAll, expectedSizes: []int{250, 500}},
+		{status: payload.PackSizeStatusActive, expectedSizes: []int{250}},
+		{status: payload.PackSizeStatusInactive, expectedSizes: []int{500}},
+		{status: "retired", expectedError: payload.ErrInvalidPackSizeStatus},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.status), func(t *testing.T) {
+			packSizes, err := service.GetAllPackSizes(models.DefaultProductID, tc.status)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			sizes := formatPackSizes(snapshotCatalog(packSizes))
+			if len(sizes) != len(tc.expectedSizes) {
+				t.Fatalf("expected sizes %v, got %v", tc.expectedSizes, sizes)
+			}
+			for i := range sizes {
+				if sizes[i] != tc.expectedSizes[i] {
+					t.Errorf("expected sizes %v, got %v", tc.expectedSizes, sizes)
+				}
+			}
+		})
+	}
+
+	// Neither the inactive nor the deleted size are in the current catalog
+	catalog, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog) != 1 || catalog[0].Size != 250 {
+		t.Errorf("expected catalog with only the 250 pack, got %v", catalog)
+	}
+
+	// Activating the size again brings it back
+	active = true
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: created[500].ID, Active: &active}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	catalog, err = service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog) != 2 {
+		t.Errorf("expected 2 pack sizes in the catalog, got %v", catalog)
+	}
+
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: uuid.New(), Active: &active}); !errors.Is(err, payload.ErrPackSizeNotFound) {
+		t.Errorf("expected ErrPackSizeNotFound, got %v", err)
+	}
+	if err := service.DeletePackSize(created[1000].ID); !errors.Is(err, payload.ErrPackSizeNotFound) {
+		t.Errorf("expected ErrPackSizeNotFound, got %v", err)
+	}
+}
+
+func TestPackSizesService_ReplacePackSizes(t *testing.T) {
+	repo := repositories.NewInMemoryPackSizesRepository()
+	service := NewPackSizesService(repo)
+
+	changes := 0
+	service.OnChange(func(productID uuid.UUID) { changes++ })
+
+	bySize := make(map[int]models.PackSize)
+	for _, size := range []int{250, 500, 1000, 2000, 5000} {
+		packSize, err := service.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: size})
+		if err != nil {
+			t.Fatalf("failed to create pack size: %v", err)
+		}
+		bySize[size] = packSize
+	}
+
+	stock := 7
+	if _, err := service.SetPackSizeStock(bySize[500].ID, &stock); err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+	inactive := false
+	if _, err := service.UpdatePackSize(payload.UpdatePackSize{ID: bySize[1000].ID, Active: &inactive}); err != nil {
+		t.Fatalf("failed to deactivate pack size: %v", err)
+	}
+
+	changes = 0
+	catalog, err := service.ReplacePackSizes(models.DefaultProductID, []int{1200, 300, 500, 1000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sizes := make([]int, len(catalog.PackSizes))
+	for i, ps := range catalog.PackSizes {
+		sizes[i] = ps.Size
+	}
+	if !reflect.DeepEqual(sizes, []int{300, 500, 1000, 1200}) {
+		t.Fatalf("expected catalog sizes [300 500 1000 1200], got %v", sizes)
+	}
+	if changes != 1 {
+		t.Errorf("expected a single change, got %d", changes)
+	}
+
+	// The replacement is the current catalog
+	current, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(current, catalog.PackSizes) {
+		t.Errorf("expected the replacement to be effective, got %v", current)
+	}
+
+	// Kept sizes keep their row and stock, and are active again
+	allPackSizes, err := service.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(allPackSizes) != 4 {
+		t.Fatalf("expected the removed sizes to be deleted, got %v", allPackSizes)
+	}
 	for _, ps := range allPackSizes {
-		if ps.ID == nonExistentPackSize.ID {
-			found = true
-			break
+		if !ps.Active {
+			t.Errorf("expected pack size %d to be active", ps.Size)
+		}
+
+		switch ps.Size {
+		case 500:
+			if ps.ID != bySize[500].ID || ps.Stock == nil || *ps.Stock != stock {
+				t.Errorf("expected pack size 500 to be kept with its stock, got %+v", ps)
+			}
+		case 1000:
+			if ps.ID != bySize[1000].ID {
+				t.Errorf("expected pack size 1000 to be kept, got %+v", ps)
+			}
 		}
 	}
-	if !found {
-		t.Error("pack size should exist after update")
+
+	testCases := []struct {
+		name          string
+		sizes         []int
+		expectedError error
+	}{
+		{name: "No sizes", sizes: []int{}, expectedError: payload.ErrNoSizes},
+		{name: "Repeated size", sizes: []int{300, 600, 300}, expectedError: payload.ErrDuplicateSize},
+		{name: "Zero size", sizes: []int{300, 0}, expectedError: payload.ErrInvalidPackSize},
+		{name: "Negative size", sizes: []int{-300}, expectedError: payload.ErrInvalidPackSize},
+		{name: "Too many sizes", sizes: make([]int, maxCatalogSizes+1), expectedError: payload.ErrTooManySizes},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes = 0
+			_, err := service.ReplacePackSizes(models.DefaultProductID, tc.sizes)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			after, err := service.GetPackSizesAt(models.DefaultProductID, time.Now())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changes != 0 || !reflect.DeepEqual(after, catalog.PackSizes) {
+				t.Errorf("expected the catalog to be unchanged, got %v", after)
+			}
+		})
 	}
 }
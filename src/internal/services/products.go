@@ -0,0 +1,145 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// foreignKeyViolation is the PostgreSQL error code raised when a row is
+// still referenced by another table.
+const foreignKeyViolation = "23503"
+
+// maxProductNameLength and maxSKULength are the sizes of the products
+// columns.
+const (
+	maxProductNameLength = 255
+	maxSKULength         = 64
+)
+
+type ProductsRepository interface {
+	GetAllProducts() ([]models.Product, error)
+	FetchProduct(productID string) (models.Product, error)
+	CreateProduct(product models.Product) (models.Product, error)
+	UpdateProduct(product models.Product) (models.Product, error)
+	DeleteProduct(productID string) (models.Product, error)
+}
+
+type ProductsService struct {
+	repo ProductsRepository
+}
+
+func NewProductsService(repo ProductsRepository) *ProductsService {
+	return &ProductsService{
+		repo: repo,
+	}
+}
+
+func (s *ProductsService) GetAllProducts() ([]models.Product, error) {
+	products, err := s.repo.GetAllProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (s *ProductsService) GetProduct(productID uuid.UUID) (models.Product, error) {
+	return fetchProduct(s.repo, productID)
+}
+
+func (s *ProductsService) CreateProduct(product models.Product) (models.Product, error) {
+	if err := validateProduct(product); err != nil {
+		return models.Product{}, err
+	}
+
+	createdProduct, err := s.repo.CreateProduct(product)
+	if err != nil {
+		return models.Product{}, skuError(err)
+	}
+
+	return createdProduct, nil
+}
+
+func (s *ProductsService) UpdateProduct(product models.Product) (models.Product, error) {
+	if err := validateProduct(product); err != nil {
+		return models.Product{}, err
+	}
+
+	updatedProduct, err := s.repo.UpdateProduct(product)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.Product{}, payload.ErrProductNotFound
+		}
+
+		return models.Product{}, skuError(err)
+	}
+
+	return updatedProduct, nil
+}
+
+// DeleteProduct removes a product together with its pack sizes and
+// catalogs. Products that were already ordered are kept for history.
+func (s *ProductsService) DeleteProduct(productID uuid.UUID) error {
+	if productID == models.DefaultProductID {
+		return payload.ErrDefaultProduct
+	}
+
+	if _, err := s.repo.DeleteProduct(productID.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return payload.ErrProductNotFound
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return payload.ErrProductInUse
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// validateProduct checks the name and SKU fit the products columns.
+func validateProduct(product models.Product) error {
+	if strings.TrimSpace(product.Name) == "" || strings.TrimSpace(product.SKU) == "" {
+		return payload.ErrMissingProductFields
+	}
+
+	if len(product.Name) > maxProductNameLength || len(product.SKU) > maxSKULength {
+		return payload.ErrProductFieldsTooLong
+	}
+
+	return nil
+}
+
+// skuError reports a write that would give two products the same SKU as
+// ErrDuplicateSKU.
+func skuError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return payload.ErrDuplicateSKU
+	}
+
+	return err
+}
+
+func fetchProduct(repo ProductsRepository, productID uuid.UUID) (models.Product, error) {
+	product, err := repo.FetchProduct(productID.String())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+			return models.Product{}, payload.ErrProductNotFound
+		}
+
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
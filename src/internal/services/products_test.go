@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestProductsService_CreateAndGetProduct(t *testing.T) {
+	repo := repositories.NewInMemoryProductsRepository()
+	service := NewProductsService(repo)
+
+	product := models.Product{
+		ID:   uuid.New(),
+		Name: "T-Shirt",
+		SKU:  "TSHIRT-001",
+	}
+
+	created, err := service.CreateProduct(product)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != product.ID || created.Name != product.Name || created.SKU != product.SKU {
+		t.Errorf("expected %+v, got %+v", product, created)
+	}
+
+	fetched, err := service.GetProduct(product.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetched.ID != product.ID {
+		t.Errorf("expected product ID %v, got %v", product.ID, fetched.ID)
+	}
+
+	// The default product plus the created one
+	products, err := service.GetAllProducts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(products) != 2 {
+		t.Errorf("expected 2 products, got %d", len(products))
+	}
+
+	_, err = service.GetProduct(uuid.New())
+	if !errors.Is(err, payload.ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestProductsService_UpdateProduct(t *testing.T) {
+	repo := repositories.NewInMemoryProductsRepository()
+	service := NewProductsService(repo)
+
+	product, err := service.CreateProduct(models.Product{ID: uuid.New(), Name: "Mug", SKU: "MUG-001"})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+
+	updated, err := service.UpdateProduct(models.Product{ID: product.ID, Name: "Large Mug", SKU: "MUG-002"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Name != "Large Mug" || updated.SKU != "MUG-002" {
+		t.Errorf("expected updated product, got %+v", updated)
+	}
+
+	_, err = service.UpdateProduct(models.Product{ID: uuid.New(), Name: "Missing", SKU: "MISSING"})
+	if !errors.Is(err, payload.ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestProductsService_DeleteProduct(t *testing.T) {
+	testCases := []struct {
+		name          string
+		productID     func(created models.Product) uuid.UUID
+		expectedError error
+	}{
+		{
+			name:          "Delete existing product",
+			productID:     func(created models.Product) uuid.UUID { return created.ID },
+			expectedError: nil,
+		},
+		{
+			name:          "Delete unknown product",
+			productID:     func(models.Product) uuid.UUID { return uuid.New() },
+			expectedError: payload.ErrProductNotFound,
+		},
+		{
+			name:          "Delete default product",
+			productID:     func(models.Product) uuid.UUID { return models.DefaultProductID },
+			expectedError: payload.ErrDefaultProduct,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := repositories.NewInMemoryProductsRepository()
+			service := NewProductsService(repo)
+
+			created, err := service.CreateProduct(models.Product{ID: uuid.New(), Name: "Cap", SKU: "CAP-001"})
+			if err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+
+			err = service.DeleteProduct(tc.productID(created))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if tc.expectedError == nil {
+				if _, err := service.GetProduct(created.ID); !errors.Is(err, payload.ErrProductNotFound) {
+					t.Errorf("expected deleted product to be gone, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestProductsService_ValidateProduct(t *testing.T) {
+	testCases := []struct {
+		name          string
+		product       models.Product
+		expectedError error
+	}{
+		{
+			name:          "Missing name",
+			product:       models.Product{SKU: "MUG-002"},
+			expectedError: payload.ErrMissingProductFields,
+		},
+		{
+			name:          "Blank SKU",
+			product:       models.Product{Name: "Mug", SKU: "  "},
+			expectedError: payload.ErrMissingProductFields,
+		},
+		{
+			name:          "SKU longer than the column",
+			product:       models.Product{Name: "Mug", SKU: strings.Repeat("M", 65)},
+			expectedError: payload.ErrProductFieldsTooLong,
+		},
+		{
+			name:          "SKU of another product",
+			product:       models.Product{Name: "Other mug", SKU: "MUG-001"},
+			expectedError: payload.ErrDuplicateSKU,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := repositories.NewInMemoryProductsRepository()
+			service := NewProductsService(repo)
+
+			existing, err := service.CreateProduct(models.Product{ID: uuid.New(), Name: "Mug", SKU: "MUG-001"})
+			if err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+			other, err := service.CreateProduct(models.Product{ID: uuid.New(), Name: "Cap", SKU: "CAP-001"})
+			if err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+
+			created := tc.product
+			created.ID = uuid.New()
+			if _, err := service.CreateProduct(created); !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v creating, got %v", tc.expectedError, err)
+			}
+
+			updated := tc.product
+			updated.ID = other.ID
+			if _, err := service.UpdateProduct(updated); !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v updating, got %v", tc.expectedError, err)
+			}
+
+			// A product keeps its own SKU when updated
+			if _, err := service.UpdateProduct(models.Product{ID: existing.ID, Name: "Big mug", SKU: existing.SKU}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// withinWeightLimit leaves out the pack sizes too heavy to ever fit in a
+// shipment.
+func withinWeightLimit(packSizes []models.PackSize, limits config.ShippingConfig) []models.PackSize {
+	if limits.MaxWeightGrams <= 0 {
+		return packSizes
+	}
+
+	result := make([]models.PackSize, 0, len(packSizes))
+	for _, ps := range packSizes {
+		if ps.WeightGrams <= limits.MaxWeightGrams {
+			result = append(result, ps)
+		}
+	}
+
+	return result
+}
+
+// shipmentGroup is the packs of one size of a line still to be shipped.
+type shipmentGroup struct {
+	lineNumber int
+	pack       models.OrderPack
+}
+
+// splitShipments splits the packs of every line of an order into shipments
+// that keep within the carrier limits. It's a first fit decreasing: the
+// heaviest packs are placed first, each in the first shipment with room
+// left for it, and a new shipment is only opened when none has. It returns
+// payload.ErrPackTooHeavy when a single pack is over the weight limit.
+func splitShipments(lines []payload.QuoteLine, limits config.ShippingConfig) ([]models.Shipment, error) {
+	var groups []shipmentGroup
+	for i, line := range lines {
+		for _, pack := range line.Packs {
+			if limits.MaxWeightGrams > 0 && pack.WeightGrams > limits.MaxWeightGrams {
+				return nil, payload.ErrPackTooHeavy
+			}
+
+			groups = append(groups, shipmentGroup{lineNumber: i + 1, pack: pack})
+		}
+	}
+
+	// Line and pack order already break ties between packs of the same weight
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].pack.WeightGrams > groups[j].pack.WeightGrams
+	})
+
+	shipments := []models.Shipment{}
+	for _, group := range groups {
+		remaining := group.pack.Quantity
+
+		for i := 0; remaining > 0; i++ {
+			if i == len(shipments) {
+				shipments = append(shipments, models.Shipment{Number: i + 1, Packs: []models.ShipmentPack{}})
+			}
+
+			count := min(remaining, shipmentRoom(shipments[i], group.pack, limits))
+			if count <= 0 {
+				continue
+			}
+
+			shipments[i].Packs = append(shipments[i].Packs, models.ShipmentPack{
+				LineNumber: group.lineNumber,
+				PackSizeID: group.pack.PackSizeID,
+				PackSize:   group.pack.PackSize,
+				Quantity:   count,
+			})
+			shipments[i].TotalPacks += count
+			shipments[i].TotalWeightGrams += count * group.pack.WeightGrams
+			shipments[i].TotalVolumeMm3 += count * group.pack.VolumeMm3
+			remaining -= count
+		}
+	}
+
+	return shipments, nil
+}
+
+// shipmentRoom returns how many more of the given pack fit in the shipment.
+func shipmentRoom(shipment models.Shipment, pack models.OrderPack, limits config.ShippingConfig) int {
+	room := pack.Quantity
+	if limits.MaxParcels > 0 {
+		room = min(room, limits.MaxParcels-shipment.TotalPacks)
+	}
+	if limits.MaxWeightGrams > 0 && pack.WeightGrams > 0 {
+		room = min(room, (limits.MaxWeightGrams-shipment.TotalWeightGrams)/pack.WeightGrams)
+	}
+
+	return room
+}
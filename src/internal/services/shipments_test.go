@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestSplitShipments(t *testing.T) {
+	heavy := models.OrderPack{PackSizeID: uuid.New(), PackSize: 5000, Quantity: 2, WeightGrams: 10000, VolumeMm3: 60}
+	light := models.OrderPack{PackSizeID: uuid.New(), PackSize: 250, Quantity: 3, WeightGrams: 500, VolumeMm3: 1}
+
+	testCases := []struct {
+		name              string
+		lines             []payload.QuoteLine
+		limits            config.ShippingConfig
+		expectedShipments [][]models.ShipmentPack
+		expectedError     error
+	}{
+		{
+			name:              "No packs",
+			lines:             []payload.QuoteLine{{}},
+			expectedShipments: [][]models.ShipmentPack{},
+		},
+		{
+			name:  "No limits ships everything together",
+			lines: []payload.QuoteLine{{Packs: []models.OrderPack{heavy, light}}},
+			expectedShipments: [][]models.ShipmentPack{
+				{{LineNumber: 1, PackSize: 5000, Quantity: 2}, {LineNumber: 1, PackSize: 250, Quantity: 3}},
+			},
+		},
+		{
+			name:   "Parcel limit",
+			lines:  []payload.QuoteLine{{Packs: []models.OrderPack{heavy, light}}},
+			limits: config.ShippingConfig{MaxParcels: 2},
+			expectedShipments: [][]models.ShipmentPack{
+				{{LineNumber: 1, PackSize: 5000, Quantity: 2}},
+				{{LineNumber: 1, PackSize: 250, Quantity: 2}},
+				{{LineNumber: 1, PackSize: 250, Quantity: 1}},
+			},
+		},
+		{
+			name:   "Weight limit fills the gaps with lighter packs",
+			lines:  []payload.QuoteLine{{Packs: []models.OrderPack{light}}, {Packs: []models.OrderPack{heavy}}},
+			limits: config.ShippingConfig{MaxWeightGrams: 11000},
+			expectedShipments: [][]models.ShipmentPack{
+				{{LineNumber: 2, PackSize: 5000, Quantity: 1}, {LineNumber: 1, PackSize: 250, Quantity: 2}},
+				{{LineNumber: 2, PackSize: 5000, Quantity: 1}, {LineNumber: 1, PackSize: 250, Quantity: 1}},
+			},
+		},
+		{
+			name:          "Pack over the weight limit",
+			lines:         []payload.QuoteLine{{Packs: []models.OrderPack{heavy}}},
+			limits:        config.ShippingConfig{MaxWeightGrams: 5000},
+			expectedError: payload.ErrPackTooHeavy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shipments, err := splitShipments(tc.lines, tc.limits)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(shipments) != len(tc.expectedShipments) {
+				t.Fatalf("expected %d shipments, got %d: %+v", len(tc.expectedShipments), len(shipments), shipments)
+			}
+
+			for i, shipment := range shipments {
+				if shipment.Number != i+1 {
+					t.Errorf("expected shipment number %d, got %d", i+1, shipment.Number)
+				}
+
+				expected := tc.expectedShipments[i]
+				if len(shipment.Packs) != len(expected) {
+					t.Fatalf("shipment %d: expected packs %+v, got %+v", i+1, expected, shipment.Packs)
+				}
+
+				weight := 0
+				for j, pack := range shipment.Packs {
+					if pack.LineNumber != expected[j].LineNumber || pack.PackSize != expected[j].PackSize || pack.Quantity != expected[j].Quantity {
+						t.Errorf("shipment %d: expected packs %+v, got %+v", i+1, expected, shipment.Packs)
+						break
+					}
+
+					if pack.PackSize == heavy.PackSize {
+						weight += pack.Quantity * heavy.WeightGrams
+					} else {
+						weight += pack.Quantity * light.WeightGrams
+					}
+				}
+
+				if shipment.TotalWeightGrams != weight {
+					t.Errorf("shipment %d: expected weight %d, got %d", i+1, weight, shipment.TotalWeightGrams)
+				}
+				if tc.limits.MaxWeightGrams > 0 && shipment.TotalWeightGrams > tc.limits.MaxWeightGrams {
+					t.Errorf("shipment %d: weight %d is over the limit", i+1, shipment.TotalWeightGrams)
+				}
+				if tc.limits.MaxParcels > 0 && shipment.TotalPacks > tc.limits.MaxParcels {
+					t.Errorf("shipment %d: %d parcels is over the limit", i+1, shipment.TotalPacks)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdersService_QuoteOrderShipments(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	// Every pack weighs 2g per item and is a 100mm wide, 100mm high box
+	// whose length grows with its size
+	for _, ps := range packSizes {
+		_, err := packSizesService.SetPackSizeDimensions(models.PackSize{
+			ID:          ps.ID,
+			WeightGrams: ps.Size * 2,
+			LengthMm:    ps.Size / 10,
+			WidthMm:     100,
+			HeightMm:    100,
+		})
+		if err != nil {
+			t.Fatalf("failed to set pack size dimensions: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		limits            config.ShippingConfig
+		expectedPackSetup string
+		expectedShipments int
+		expectedError     error
+	}{
+		{
+			name:              "No limits",
+			expectedPackSetup: "2x5000, 1x2000, 1x250",
+			expectedShipments: 1,
+		},
+		{
+			name:              "One parcel per shipment",
+			limits:            config.ShippingConfig{MaxParcels: 1},
+			expectedPackSetup: "2x5000, 1x2000, 1x250",
+			expectedShipments: 4,
+		},
+		{
+			name:              "Weight limit",
+			limits:            config.ShippingConfig{MaxWeightGrams: 15000},
+			expectedPackSetup: "2x5000, 1x2000, 1x250",
+			expectedShipments: 2,
+		},
+		{
+			name:              "Packs over the weight limit aren't used",
+			limits:            config.ShippingConfig{MaxWeightGrams: 5000},
+			expectedPackSetup: "6x2000, 1x250",
+			expectedShipments: 6,
+		},
+		{
+			name:          "Every pack over the weight limit",
+			limits:        config.ShippingConfig{MaxWeightGrams: 100},
+			expectedError: payload.ErrPackTooHeavy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := NewOrdersService(
+				repositories.NewInMemoryOrdersRepository(),
+				packSizesRepo,
+				repositories.NewInMemoryProductsRepository(),
+				config.OrdersConfig{HandlingCostCents: 300, Shipping: tc.limits},
+			)
+
+			quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 12001})
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if quote.PackSetup != tc.expectedPackSetup {
+				t.Errorf("expected pack setup %q, got %q", tc.expectedPackSetup, quote.PackSetup)
+			}
+			if len(quote.Shipments) != tc.expectedShipments {
+				t.Errorf("expected %d shipments, got %d", tc.expectedShipments, len(quote.Shipments))
+			}
+			if quote.HandlingCostCents != 300*tc.expectedShipments {
+				t.Errorf("expected handling cost %d, got %d", 300*tc.expectedShipments, quote.HandlingCostCents)
+			}
+
+			// 2g and 100mm x 100mm x 0.1mm per item shipped
+			if quote.TotalWeightGrams != quote.ShippedItems*2 {
+				t.Errorf("expected total weight %d, got %d", quote.ShippedItems*2, quote.TotalWeightGrams)
+			}
+			if quote.TotalVolumeMm3 != quote.ShippedItems*1000 {
+				t.Errorf("expected total volume %d, got %d", quote.ShippedItems*1000, quote.TotalVolumeMm3)
+			}
+
+			shippedWeight := 0
+			for _, shipment := range quote.Shipments {
+				shippedWeight += shipment.TotalWeightGrams
+			}
+			if shippedWeight != quote.TotalWeightGrams {
+				t.Errorf("expected the shipments to weigh %d, got %d", quote.TotalWeightGrams, shippedWeight)
+			}
+		})
+	}
+
+	if _, err := packSizesService.SetPackSizeDimensions(models.PackSize{ID: packSizes[0].ID, WeightGrams: -1}); !errors.Is(err, payload.ErrInvalidWeight) {
+		t.Errorf("expected ErrInvalidWeight, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// residueTable describes every amount of items a set of pack sizes can make
+// without building a table as large as the amount. Sizes are divided by
+// their gcd and amounts are grouped by their residue modulo the largest
+// pack: weight[r] is the lowest sum of (largest - size) over the smaller
+// packs that make an amount congruent to r, base[r] that amount and
+// parent[r] the last pack on the way there. Any amount t >= base[r] in the
+// residue class is then made with the fewest packs as the path to r plus
+// (t - base[r]) / largest packs of the largest size, in
+// (t + weight[r]) / largest packs. Below threshold, the largest base, that
+// doesn't hold for every residue so the plain table is used instead.
+type residueTable struct {
+	gcd       int
+	sizes     []int
+	largest   int
+	weight    []int
+	base      []int
+	parent    []int
+	threshold int
+}
+
+// buildResidueTable runs a Dijkstra over the residues modulo the largest
+// pack, where using a pack of size p moves from r to (r + p) mod largest at
+// a cost of largest - p. Ties are broken by the smaller base so the
+// threshold stays as low as possible. It takes O(largest * len(sizes))
+// memory and time, whatever the amount of items.
+func buildResidueTable(packSizes []int) *residueTable {
+	g := 0
+	for _, size := range packSizes {
+		g = gcd(g, size)
+	}
+
+	sizes := make([]int, len(packSizes))
+	for i, size := range packSizes {
+		sizes[i] = size / g
+	}
+	sort.Ints(sizes)
+
+	largest := sizes[len(sizes)-1]
+	table := &residueTable{
+		gcd:     g,
+		sizes:   sizes,
+		largest: largest,
+		weight:  make([]int, largest),
+		base:    make([]int, largest),
+		parent:  make([]int, largest),
+	}
+
+	for r := range table.weight {
+		table.weight[r] = -1
+	}
+	table.weight[0] = 0
+
+	done := make([]bool, largest)
+	queue := &residueQueue{{}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(residueEntry)
+		if done[current.residue] {
+			continue
+		}
+		done[current.residue] = true
+		table.threshold = max(table.threshold, current.base)
+
+		for _, size := range sizes {
+			if size == largest {
+				continue
+			}
+
+			next := (current.residue + size) % largest
+			weight := current.weight + largest - size
+			base := current.base + size
+			if done[next] || (table.weight[next] != -1 && (weight > table.weight[next] || (weight == table.weight[next] && base >= table.base[next]))) {
+				continue
+			}
+
+			table.weight[next] = weight
+			table.base[next] = base
+			table.parent[next] = size
+			heap.Push(queue, residueEntry{residue: next, weight: weight, base: base})
+		}
+	}
+
+	return table
+}
+
+// packs returns the fewest packs making exactly amount, in units of the
+// gcd, or math.MaxInt32 when it can't be made. amount must be at least the
+// threshold.
+func (t *residueTable) packs(amount int) int {
+	r := amount % t.largest
+	if t.weight[r] == -1 {
+		return math.MaxInt32
+	}
+
+	return (amount + t.weight[r]) / t.largest
+}
+
+// combination returns the packs making exactly amount, in units of the gcd,
+// keyed by their actual size.
+func (t *residueTable) combination(amount int) map[int]int {
+	packs := make(map[int]int)
+
+	r := amount % t.largest
+	remaining := amount - t.base[r]
+	for r != 0 {
+		size := t.parent[r]
+		packs[size*t.gcd]++
+		r = ((r-size)%t.largest + t.largest) % t.largest
+	}
+
+	if remaining > 0 {
+		packs[t.largest*t.gcd] += remaining / t.largest
+	}
+
+	return packs
+}
+
+type residueEntry struct {
+	residue int
+	weight  int
+	base    int
+}
+
+// residueQueue is a min-heap of residues by weight, then base.
+type residueQueue []residueEntry
+
+func (q residueQueue) Len() int { return len(q) }
+
+func (q residueQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+
+	return q[i].base < q[j].base
+}
+
+func (q residueQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *residueQueue) Push(x any) { *q = append(*q, x.(residueEntry)) }
+
+func (q *residueQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return entry
+}
+
+// normalizePackSizes returns the distinct positive sizes in ascending order,
+// whatever the order and contents of the catalog they came from.
+func normalizePackSizes(packSizes []int) []int {
+	sizes := make([]int, 0, len(packSizes))
+	for _, size := range packSizes {
+		if size > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Ints(sizes)
+
+	distinct := sizes[:0]
+	for i, size := range sizes {
+		if i == 0 || size != sizes[i-1] {
+			distinct = append(distinct, size)
+		}
+	}
+
+	return distinct
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
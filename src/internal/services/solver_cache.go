@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// solverCache keeps the residue tables of the pack size sets in use by every
+// product, keyed by a hash of the sizes, so orders against a catalog that
+// didn't change skip building them. Concurrent requests for a table that
+// isn't built yet wait for a single build, and built tables are never
+// modified so they're shared freely. A nil cache builds a new table every
+// time.
+//
+// The key is enough for a table to always match its sizes, so a product's
+// tables are only dropped when its pack sizes change to keep the ones no
+// longer in use from piling up.
+type solverCache struct {
+	mu       sync.RWMutex
+	products map[uuid.UUID]map[string]*solverCacheEntry
+}
+
+type solverCacheEntry struct {
+	once  sync.Once
+	table *residueTable
+}
+
+func newSolverCache() *solverCache {
+	return &solverCache{products: make(map[uuid.UUID]map[string]*solverCacheEntry)}
+}
+
+// productTables is the part of a cache holding the tables of a single
+// product. The zero value builds a new table every time.
+type productTables struct {
+	cache     *solverCache
+	productID uuid.UUID
+}
+
+func (c *solverCache) product(productID uuid.UUID) productTables {
+	return productTables{cache: c, productID: productID}
+}
+
+// table returns the residue table of the pack sizes, building it on the
+// first request.
+func (t productTables) table(packSizes []int) *residueTable {
+	c := t.cache
+	if c == nil {
+		return buildResidueTable(packSizes)
+	}
+
+	key := packSizesKey(packSizes)
+
+	c.mu.RLock()
+	entry, exists := c.products[t.productID][key]
+	c.mu.RUnlock()
+
+	if !exists {
+		c.mu.Lock()
+		entries, tracked := c.products[t.productID]
+		if !tracked {
+			entries = make(map[string]*solverCacheEntry)
+			c.products[t.productID] = entries
+		}
+
+		entry, exists = entries[key]
+		if !exists {
+			entry = &solverCacheEntry{}
+			entries[key] = entry
+		}
+		c.mu.Unlock()
+	}
+
+	entry.once.Do(func() {
+		entry.table = buildResidueTable(packSizes)
+	})
+
+	return entry.table
+}
+
+// invalidate drops the tables of the product, or of every product for
+// uuid.Nil. They're built again as orders need them.
+func (c *solverCache) invalidate(productID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if productID == uuid.Nil {
+		c.products = make(map[uuid.UUID]map[string]*solverCacheEntry)
+		return
+	}
+
+	delete(c.products, productID)
+}
+
+func (c *solverCache) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	count := 0
+	for _, entries := range c.products {
+		count += len(entries)
+	}
+
+	return count
+}
+
+// packSizesKey hashes the pack sizes regardless of their order.
+func packSizesKey(packSizes []int) string {
+	sizes := make([]int, len(packSizes))
+	copy(sizes, packSizes)
+	sort.Ints(sizes)
+
+	hash := sha256.New()
+	for _, size := range sizes {
+		_ = binary.Write(hash, binary.BigEndian, int64(size))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
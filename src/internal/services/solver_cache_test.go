@@ -0,0 +1,129 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+func TestSolverCache(t *testing.T) {
+	cache := newSolverCache()
+	product := cache.product(models.DefaultProductID)
+	other := cache.product(uuid.New())
+
+	table := product.table([]int{250, 500, 1000})
+	if product.table([]int{1000, 250, 500}) != table {
+		t.Error("expected the same sizes in any order to share a table")
+	}
+	if product.table([]int{250, 500}) == table {
+		t.Error("expected different sizes to get their own table")
+	}
+	otherTable := other.table([]int{250, 500, 1000})
+	if cache.len() != 3 {
+		t.Errorf("expected 3 tables, got %d", cache.len())
+	}
+
+	// Concurrent requests share a single build
+	tables := make([]*residueTable, 50)
+	var wg sync.WaitGroup
+	for i := range tables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tables[i] = product.table([]int{23, 31, 53})
+		}()
+	}
+	wg.Wait()
+
+	for _, got := range tables {
+		if got != tables[0] {
+			t.Fatal("expected concurrent requests to get the same table")
+		}
+	}
+
+	// Only the product's tables are dropped
+	cache.invalidate(models.DefaultProductID)
+	if cache.len() != 1 {
+		t.Errorf("expected only the other product's table after invalidation, got %d", cache.len())
+	}
+	if product.table([]int{250, 500, 1000}) == table {
+		t.Error("expected the table to be built again after invalidation")
+	}
+	if other.table([]int{250, 500, 1000}) != otherTable {
+		t.Error("expected the other product's table to be kept")
+	}
+
+	cache.invalidate(uuid.Nil)
+	if cache.len() != 0 {
+		t.Errorf("expected no tables after invalidating every product, got %d", cache.len())
+	}
+
+	if (productTables{}).table([]int{250, 500}) == nil {
+		t.Error("expected a nil cache to build the table")
+	}
+}
+
+func TestOrdersService_SolverCacheInvalidatedOnPackSizeChange(t *testing.T) {
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(
+		repositories.NewInMemoryOrdersRepository(),
+		packSizesRepo,
+		repositories.NewInMemoryProductsRepository(),
+		config.OrdersConfig{},
+	)
+	packSizesService.OnChange(service.InvalidateSolverCache)
+
+	quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 12001})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.PackSetup != "2x5000, 1x2000, 1x250" {
+		t.Errorf("expected pack setup 2x5000, 1x2000, 1x250, got %q", quote.PackSetup)
+	}
+	if service.solverTables.len() != 1 {
+		t.Fatalf("expected the table to be cached, got %d tables", service.solverTables.len())
+	}
+
+	_, err = packSizesService.CreatePackSize(models.PackSize{ID: uuid.New(), ProductID: models.DefaultProductID, Size: 12001})
+	if err != nil {
+		t.Fatalf("failed to create pack size: %v", err)
+	}
+	if service.solverTables.len() != 0 {
+		t.Errorf("expected the cache to be invalidated, got %d tables", service.solverTables.len())
+	}
+
+	quote, err = service.QuoteOrder(payload.CreateOrder{ItemsCount: 12001})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.PackSetup != "1x12001" {
+		t.Errorf("expected the new pack size to be used, got %q", quote.PackSetup)
+	}
+}
+
+func BenchmarkSolveUnbounded(b *testing.B) {
+	packSizes := []int{9973, 10007, 49999}
+
+	b.Run("without cache", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			solveUnbounded(500000000, packSizes, productTables{}, findBestTarget)
+		}
+	})
+
+	b.Run("with cache", func(b *testing.B) {
+		tables := newSolverCache().product(models.DefaultProductID)
+		b.ReportAllocs()
+		for b.Loop() {
+			solveUnbounded(500000000, packSizes, tables, findBestTarget)
+		}
+	})
+}
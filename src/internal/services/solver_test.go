@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+// referenceResult is the best packing found by bruteForcePackCombination.
+type referenceResult struct {
+	found      bool
+	totalItems int
+	totalPacks int
+}
+
+// bruteForcePackCombination tries every number of packs of every size, up
+// to the stock of the tracked ones, and keeps the best packing that covers
+// itemsCount: the fewest items then packs, or the fewest packs then items.
+// Adding a pack to a packing that already covers the items never makes it
+// better, so those aren't extended.
+func bruteForcePackCombination(itemsCount int, packSizes []int, stock map[int]int, fewestPacks bool) referenceResult {
+	seen := make(map[int]bool)
+	var sizes []int
+	for _, size := range packSizes {
+		if size > 0 && !seen[size] {
+			seen[size] = true
+			sizes = append(sizes, size)
+		}
+	}
+
+	var best referenceResult
+	better := func(items, packs int) bool {
+		if !best.found {
+			return true
+		}
+		if fewestPacks {
+			return packs < best.totalPacks || (packs == best.totalPacks && items < best.totalItems)
+		}
+
+		return items < best.totalItems || (items == best.totalItems && packs < best.totalPacks)
+	}
+
+	var try func(i, items, packs int)
+	try = func(i, items, packs int) {
+		if items >= itemsCount {
+			if better(items, packs) {
+				best = referenceResult{found: true, totalItems: items, totalPacks: packs}
+			}
+			return
+		}
+		if i == len(sizes) {
+			return
+		}
+
+		available, tracked := stock[sizes[i]]
+		for count := 0; !tracked || count <= available; count++ {
+			try(i+1, items+count*sizes[i], packs+count)
+			if items+count*sizes[i] >= itemsCount {
+				break
+			}
+		}
+	}
+	try(0, 0, 0)
+
+	return best
+}
+
+// checkAgainstReference compares a solver result with the brute force one
+// and checks that the packs it returns add up to its totals.
+func checkAgainstReference(t *testing.T, result PackCombinationResult, reference referenceResult, packSizes []int, stock map[int]int) {
+	t.Helper()
+
+	if result.TotalItems != reference.totalItems || result.TotalPacks != reference.totalPacks {
+		t.Fatalf("sizes %v: expected %d items in %d packs, got %d items in %d packs",
+			packSizes, reference.totalItems, reference.totalPacks, result.TotalItems, result.TotalPacks)
+	}
+
+	items, packs := 0, 0
+	for size, count := range result.Packs {
+		found := false
+		for _, packSize := range packSizes {
+			found = found || packSize == size
+		}
+		if !found || size <= 0 {
+			t.Fatalf("sizes %v: packed with unknown size %d", packSizes, size)
+		}
+		if available, tracked := stock[size]; tracked && count > available {
+			t.Fatalf("sizes %v: used %d packs of %d with only %d in stock", packSizes, count, size, available)
+		}
+
+		items += size * count
+		packs += count
+	}
+
+	if items != result.TotalItems || packs != result.TotalPacks {
+		t.Fatalf("sizes %v: packs %v don't add up to %d items in %d packs", packSizes, result.Packs, result.TotalItems, result.TotalPacks)
+	}
+}
+
+// fuzzPackSizes turns the fuzzer's bytes into small pack sizes, a zero byte
+// leaving the size out, so the brute force stays fast.
+func fuzzPackSizes(values ...uint8) []int {
+	var sizes []int
+	for _, value := range values {
+		if value != 0 {
+			sizes = append(sizes, 2+int(value)%40)
+		}
+	}
+
+	return sizes
+}
+
+// FuzzCalculatePackCombination checks the solver against a brute-force one:
+//
+//	go test ./src/internal/services -fuzz FuzzCalculatePackCombination$
+func FuzzCalculatePackCombination(f *testing.F) {
+	f.Add(uint8(1), uint8(23), uint8(31), uint8(53), uint8(0), false)
+	f.Add(uint8(120), uint8(1), uint8(2), uint8(3), uint8(4), false)
+	f.Add(uint8(77), uint8(40), uint8(3), uint8(39), uint8(3), true)
+	f.Add(uint8(10), uint8(0), uint8(0), uint8(0), uint8(0), false)
+	f.Add(uint8(0), uint8(0), uint8(0), uint8(0), uint8(0), true)
+
+	f.Fuzz(func(t *testing.T, rawItemsCount, a, b, c, d uint8, fewestPacks bool) {
+		itemsCount := int(rawItemsCount) % 121
+		packSizes := fuzzPackSizes(a, b, c, d)
+
+		selectTarget := findBestTarget
+		if fewestPacks {
+			selectTarget = findFewestPacksTarget
+		}
+
+		result, err := solveUnbounded(itemsCount, packSizes, productTables{}, selectTarget)
+		if itemsCount > 0 && len(packSizes) == 0 {
+			if !errors.Is(err, payload.ErrNoPackSizes) {
+				t.Fatalf("expected ErrNoPackSizes, got %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		reference := bruteForcePackCombination(itemsCount, packSizes, nil, fewestPacks)
+		checkAgainstReference(t, result, reference, packSizes, nil)
+
+		// The order of the catalog doesn't matter
+		reversed := make([]int, len(packSizes))
+		copy(reversed, packSizes)
+		sort.Sort(sort.Reverse(sort.IntSlice(reversed)))
+
+		result, err = solveUnbounded(itemsCount, reversed, newSolverCache().product(models.DefaultProductID), selectTarget)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkAgainstReference(t, result, reference, reversed, nil)
+	})
+}
+
+func FuzzCalculatePackCombinationWithStock(f *testing.F) {
+	f.Add(uint8(11), uint8(3), uint8(5), uint8(0), uint8(1), uint8(255), uint8(0), false)
+	f.Add(uint8(13), uint8(3), uint8(10), uint8(0), uint8(5), uint8(0), uint8(0), false)
+	f.Add(uint8(100), uint8(7), uint8(11), uint8(13), uint8(2), uint8(3), uint8(255), true)
+	f.Add(uint8(50), uint8(10), uint8(0), uint8(0), uint8(4), uint8(0), uint8(0), false)
+
+	f.Fuzz(func(t *testing.T, rawItemsCount, a, b, c, stockA, stockB, stockC uint8, fewestPacks bool) {
+		itemsCount := int(rawItemsCount) % 121
+
+		// Stock values of 200 and up leave the size untracked
+		stock := make(map[int]int)
+		var packSizes []int
+		for i, value := range []uint8{a, b, c} {
+			if value == 0 {
+				continue
+			}
+
+			size := 2 + int(value)%40
+			packSizes = append(packSizes, size)
+			if available := []uint8{stockA, stockB, stockC}[i]; available < 200 {
+				stock[size] = int(available) % 8
+			}
+		}
+
+		selectTarget := findBestTarget
+		if fewestPacks {
+			selectTarget = findFewestPacksTarget
+		}
+
+		result, err := solveWithStock(itemsCount, packSizes, stock, productTables{}, 0, selectTarget)
+		if itemsCount > 0 && len(packSizes) == 0 {
+			if !errors.Is(err, payload.ErrNoPackSizes) {
+				t.Fatalf("expected ErrNoPackSizes, got %v", err)
+			}
+			return
+		}
+
+		reference := bruteForcePackCombination(itemsCount, packSizes, stock, fewestPacks)
+		if !reference.found {
+			if !errors.Is(err, payload.ErrInsufficientStock) {
+				t.Fatalf("sizes %v stock %v: expected ErrInsufficientStock, got %v", packSizes, stock, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("sizes %v stock %v: unexpected error: %v", packSizes, stock, err)
+		}
+
+		checkAgainstReference(t, result, reference, packSizes, stock)
+	})
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+const (
+	StrategyFewestItems = "fewest-items"
+	StrategyFewestPacks = "fewest-packs"
+	StrategyGreedy      = "greedy"
+	StrategyCheapest    = "cheapest"
+)
+
+// PackingStrategy picks the packs that fulfil a line of an order out of the
+// product's pack sizes. A pack size with a nil Stock is unlimited, otherwise
+// Stock holds the packs of it that can still be used.
+type PackingStrategy interface {
+	Name() string
+	Calculate(itemsCount int, packSizes []models.PackSize) (PackCombinationResult, error)
+}
+
+// newPackingStrategies returns every strategy by name, the ones built on the
+// unbounded solver sharing the given table cache. Lines solved with tracked
+// stock or by cost are capped at maxBoundedItems, zero meaning no cap.
+func newPackingStrategies(tables *solverCache, maxBoundedItems int) map[string]PackingStrategy {
+	return map[string]PackingStrategy{
+		StrategyFewestItems: fewestItemsStrategy{tables: tables, maxBoundedItems: maxBoundedItems},
+		StrategyFewestPacks: fewestPacksStrategy{tables: tables, maxBoundedItems: maxBoundedItems},
+		StrategyGreedy:      greedyStrategy{},
+		StrategyCheapest:    cheapestStrategy{maxBoundedItems: maxBoundedItems},
+	}
+}
+
+// packingStrategy returns the strategy with the given name, or the default
+// one when the name is empty.
+func packingStrategy(strategies map[string]PackingStrategy, name, defaultName string) (PackingStrategy, error) {
+	if name == "" {
+		name = defaultName
+	}
+	if name == "" {
+		name = StrategyFewestItems
+	}
+
+	strategy, exists := strategies[name]
+	if !exists {
+		return nil, payload.ErrUnknownStrategy
+	}
+
+	return strategy, nil
+}
+
+// fewestItemsStrategy ships the fewest items possible and, among the
+// packings that do, uses the fewest packs.
+type fewestItemsStrategy struct {
+	tables          *solverCache
+	maxBoundedItems int
+}
+
+func (fewestItemsStrategy) Name() string {
+	return StrategyFewestItems
+}
+
+func (s fewestItemsStrategy) Calculate(itemsCount int, packSizes []models.PackSize) (PackCombinationResult, error) {
+	sizes, stock := splitPackSizes(packSizes)
+
+	return solveWithStock(itemsCount, sizes, stock, s.tables.product(packSizesProduct(packSizes)), s.maxBoundedItems, findBestTarget)
+}
+
+// fewestPacksStrategy uses the fewest packs possible and, among the
+// packings that do, ships the fewest items.
+type fewestPacksStrategy struct {
+	tables          *solverCache
+	maxBoundedItems int
+}
+
+func (fewestPacksStrategy) Name() string {
+	return StrategyFewestPacks
+}
+
+func (s fewestPacksStrategy) Calculate(itemsCount int, packSizes []models.PackSize) (PackCombinationResult, error) {
+	sizes, stock := splitPackSizes(packSizes)
+
+	return solveWithStock(itemsCount, sizes, stock, s.tables.product(packSizesProduct(packSizes)), s.maxBoundedItems, findFewestPacksTarget)
+}
+
+// greedyStrategy fills the order with as many of the largest packs as fit,
+// then the next largest and so on, closing the remainder with the smallest
+// pack that covers it. It's fast and predictable but can ship more items
+// and packs than the other strategies.
+type greedyStrategy struct{}
+
+func (greedyStrategy) Name() string {
+	return StrategyGreedy
+}
+
+func (greedyStrategy) Calculate(itemsCount int, packSizes []models.PackSize) (PackCombinationResult, error) {
+	result := PackCombinationResult{Packs: make(map[int]int)}
+	if itemsCount <= 0 {
+		return result, nil
+	}
+
+	sizes, stock := splitPackSizes(packSizes)
+	sizes = normalizePackSizes(sizes)
+	if len(sizes) == 0 {
+		return PackCombinationResult{}, payload.ErrNoPackSizes
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	available := func(size int) int {
+		if left, tracked := stock[size]; tracked {
+			return left - result.Packs[size]
+		}
+
+		return itemsCount/size + 1
+	}
+
+	add := func(size, count int) {
+		result.Packs[size] += count
+		result.TotalPacks += count
+		result.TotalItems += size * count
+	}
+
+	remaining := itemsCount
+	for _, size := range sizes {
+		if count := min(remaining/size, available(size)); count > 0 {
+			add(size, count)
+			remaining -= size * count
+		}
+	}
+
+	for remaining > 0 {
+		// The smallest pack that covers the remainder, or the largest one
+		// left when none does
+		chosen := 0
+		for _, size := range sizes {
+			if available(size) <= 0 {
+				continue
+			}
+
+			if chosen == 0 || size >= remaining {
+				chosen = size
+			}
+		}
+
+		if chosen == 0 {
+			return PackCombinationResult{}, payload.ErrInsufficientStock
+		}
+
+		add(chosen, 1)
+		remaining -= chosen
+	}
+
+	return result, nil
+}
+
+// cheapestStrategy ships the packing with the lowest total unit cost and,
+// among the ones that cost the same, the fewest items and then packs. Its
+// solver takes time and memory proportional to the items count, so it
+// refuses counts over maxBoundedItems.
+type cheapestStrategy struct {
+	maxBoundedItems int
+}
+
+func (cheapestStrategy) Name() string {
+	return StrategyCheapest
+}
+
+func (s cheapestStrategy) Calculate(itemsCount int, packSizes []models.PackSize) (PackCombinationResult, error) {
+	if itemsCount <= 0 {
+		return PackCombinationResult{Packs: make(map[int]int)}, nil
+	}
+
+	sizes, stock := splitPackSizes(packSizes)
+	sizes = normalizePackSizes(sizes)
+	if len(sizes) == 0 {
+		return PackCombinationResult{}, payload.ErrNoPackSizes
+	}
+
+	if err := checkBoundedItems(itemsCount, s.maxBoundedItems); err != nil {
+		return PackCombinationResult{}, err
+	}
+
+	largest := sizes[len(sizes)-1]
+	costs := make(map[int]int, len(packSizes))
+	for _, ps := range packSizes {
+		costs[ps.Size] = ps.UnitCostCents
+	}
+
+	// Costs are never negative, so dropping a pack from a packing past
+	// itemsCount + largest pack would cost the same or less with fewer items
+	maxTarget := itemsCount + largest
+
+	items := splitBoundedPacks(maxTarget, sizes, stock)
+	cost, packs, taken := buildCheapestDP(maxTarget, items, costs)
+
+	bestTarget := -1
+	for target := itemsCount; target <= maxTarget; target++ {
+		if cost[target] != math.MaxInt && (bestTarget == -1 || cost[target] < cost[bestTarget]) {
+			bestTarget = target
+		}
+	}
+
+	if bestTarget == -1 {
+		return PackCombinationResult{}, payload.ErrInsufficientStock
+	}
+
+	return PackCombinationResult{
+		Packs:      collectBoundedPacks(items, taken, bestTarget),
+		TotalPacks: packs[bestTarget],
+		TotalItems: bestTarget,
+	}, nil
+}
+
+// buildCheapestDP runs a 0/1 knapsack over the bundles: cost[i] is the
+// lowest cost to make exactly i items, packs[i] the fewest packs to do it
+// at that cost and taken[n] a bitset of the targets whose best value used
+// the n-th bundle.
+func buildCheapestDP(maxTarget int, items []boundedPack, costs map[int]int) ([]int, []int, [][]uint64) {
+	cost := make([]int, maxTarget+1)
+	packs := make([]int, maxTarget+1)
+	for i := range cost {
+		cost[i] = math.MaxInt
+	}
+	cost[0] = 0
+
+	taken := make([][]uint64, len(items))
+	for n, item := range items {
+		taken[n] = make([]uint64, maxTarget/64+1)
+		weight := item.size * item.count
+		itemCost := costs[item.size] * item.count
+
+		for i := maxTarget; i >= weight; i-- {
+			if cost[i-weight] == math.MaxInt {
+				continue
+			}
+
+			candidateCost := cost[i-weight] + itemCost
+			candidatePacks := packs[i-weight] + item.count
+			if candidateCost < cost[i] || (candidateCost == cost[i] && candidatePacks < packs[i]) {
+				cost[i] = candidateCost
+				packs[i] = candidatePacks
+				taken[n][i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+
+	return cost, packs, taken
+}
+
+// packSizesProduct is the product the pack sizes belong to, or uuid.Nil when
+// there are none.
+func packSizesProduct(packSizes []models.PackSize) uuid.UUID {
+	if len(packSizes) == 0 {
+		return uuid.Nil
+	}
+
+	return packSizes[0].ProductID
+}
+
+// splitPackSizes returns the sizes to pack with and the packs left of every
+// size whose stock is tracked.
+func splitPackSizes(packSizes []models.PackSize) ([]int, map[int]int) {
+	sizes := make([]int, len(packSizes))
+	stock := make(map[int]int)
+	for i, ps := range packSizes {
+		sizes[i] = ps.Size
+		if ps.Stock != nil {
+			stock[ps.Size] = *ps.Stock
+		}
+	}
+
+	return sizes, stock
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/config"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/mocks/repositories"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
+)
+
+type strategyTestCase struct {
+	name          string
+	itemsCount    int
+	packSizes     []int
+	stock         map[int]int
+	costs         map[int]int
+	expectedPacks map[int]int
+	expectedError error
+}
+
+func TestFewestItemsStrategy(t *testing.T) {
+	runStrategyTestCases(t, fewestItemsStrategy{}, []strategyTestCase{
+		{
+			name:          "Zero items",
+			itemsCount:    0,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{},
+		},
+		{
+			name:          "501 ships 750 items in 2 packs",
+			itemsCount:    501,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{500: 1, 250: 1},
+		},
+		{
+			name:          "12001 items",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{5000: 2, 2000: 1, 250: 1},
+		},
+		{
+			name:          "Exact items with small packs",
+			itemsCount:    6,
+			packSizes:     []int{3, 5},
+			expectedPacks: map[int]int{3: 2},
+		},
+		{
+			name:          "Out of the largest pack",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 0},
+			expectedPacks: map[int]int{2000: 6, 250: 1},
+		},
+	})
+}
+
+func TestFewestPacksStrategy(t *testing.T) {
+	runStrategyTestCases(t, fewestPacksStrategy{}, []strategyTestCase{
+		{
+			name:          "Zero items",
+			itemsCount:    0,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{},
+		},
+		{
+			name:          "1 item needs 1x250",
+			itemsCount:    1,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{250: 1},
+		},
+		{
+			name:          "501 ships a single 1000 pack",
+			itemsCount:    501,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{1000: 1},
+		},
+		{
+			name:          "12001 ships 3 packs of 5000",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{5000: 3},
+		},
+		{
+			name:          "500000 items with custom pack sizes 23, 31, 53",
+			itemsCount:    500000,
+			packSizes:     []int{23, 31, 53},
+			expectedPacks: map[int]int{53: 9434},
+		},
+		{
+			name:          "Only one of the largest pack left",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 1},
+			expectedPacks: map[int]int{5000: 1, 2000: 4},
+		},
+		{
+			name:          "Not enough packs in stock",
+			itemsCount:    251,
+			packSizes:     []int{250},
+			stock:         map[int]int{250: 1},
+			expectedError: payload.ErrInsufficientStock,
+		},
+	})
+}
+
+func TestGreedyStrategy(t *testing.T) {
+	runStrategyTestCases(t, greedyStrategy{}, []strategyTestCase{
+		{
+			name:          "Zero items",
+			itemsCount:    0,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{},
+		},
+		{
+			name:          "1 item needs 1x250",
+			itemsCount:    1,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{250: 1},
+		},
+		{
+			name:          "501 items",
+			itemsCount:    501,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{500: 1, 250: 1},
+		},
+		{
+			name:          "12001 items",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{5000: 2, 2000: 1, 250: 1},
+		},
+		{
+			name:          "Largest first overshoots where smaller packs fit exactly",
+			itemsCount:    6,
+			packSizes:     []int{3, 5},
+			expectedPacks: map[int]int{5: 1, 3: 1},
+		},
+		{
+			name:          "Only one of the largest pack left",
+			itemsCount:    12001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 1},
+			expectedPacks: map[int]int{5000: 1, 2000: 3, 1000: 1, 250: 1},
+		},
+		{
+			name:          "Remainder closed with the last pack in stock",
+			itemsCount:    600,
+			packSizes:     []int{250},
+			stock:         map[int]int{250: 3},
+			expectedPacks: map[int]int{250: 3},
+		},
+		{
+			name:          "Not enough packs in stock",
+			itemsCount:    600,
+			packSizes:     []int{250},
+			stock:         map[int]int{250: 2},
+			expectedError: payload.ErrInsufficientStock,
+		},
+	})
+}
+
+func TestCheapestStrategy(t *testing.T) {
+	bulkDiscount := map[int]int{250: 100, 500: 180, 1000: 300, 2000: 500, 5000: 1000}
+
+	runStrategyTestCases(t, cheapestStrategy{}, []strategyTestCase{
+		{
+			name:          "Zero items",
+			itemsCount:    0,
+			packSizes:     defaultPackSizes,
+			costs:         bulkDiscount,
+			expectedPacks: map[int]int{},
+		},
+		{
+			name:          "A large pack is cheaper than fewer items",
+			itemsCount:    4001,
+			packSizes:     defaultPackSizes,
+			costs:         bulkDiscount,
+			expectedPacks: map[int]int{5000: 1},
+		},
+		{
+			name:          "Same cost falls back to fewest items and packs",
+			itemsCount:    501,
+			packSizes:     defaultPackSizes,
+			expectedPacks: map[int]int{500: 1, 250: 1},
+		},
+		{
+			name:          "Many cheap small packs",
+			itemsCount:    10,
+			packSizes:     []int{3, 5},
+			costs:         map[int]int{3: 1, 5: 10},
+			expectedPacks: map[int]int{3: 4},
+		},
+		{
+			name:          "Out of the cheapest pack",
+			itemsCount:    4001,
+			packSizes:     defaultPackSizes,
+			stock:         map[int]int{5000: 0},
+			costs:         bulkDiscount,
+			expectedPacks: map[int]int{2000: 2, 250: 1},
+		},
+		{
+			name:          "Not enough packs in stock",
+			itemsCount:    251,
+			packSizes:     []int{250},
+			stock:         map[int]int{250: 1},
+			costs:         bulkDiscount,
+			expectedError: payload.ErrInsufficientStock,
+		},
+	})
+}
+
+func TestOrdersService_QuoteOrderCost(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	packSizesService := NewPackSizesService(packSizesRepo)
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{
+		HandlingCostCents: 250,
+	})
+
+	packSizes, err := packSizesService.GetAllPackSizes(models.DefaultProductID, payload.PackSizeStatusAll)
+	if err != nil {
+		t.Fatalf("failed to get pack sizes: %v", err)
+	}
+
+	costs := map[int]int{250: 100, 500: 180, 1000: 300, 2000: 500, 5000: 1000}
+	for _, ps := range packSizes {
+		cost := costs[ps.Size]
+		if _, err := packSizesService.UpdatePackSize(payload.UpdatePackSize{ID: ps.ID, UnitCostCents: &cost}); err != nil {
+			t.Fatalf("failed to set pack size cost: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		strategy          string
+		expectedPackSetup string
+		expectedCost      int
+	}{
+		{
+			name:              "Fewest items",
+			strategy:          StrategyFewestItems,
+			expectedPackSetup: "2x2000, 1x250",
+			expectedCost:      1100 + 250,
+		},
+		{
+			name:              "Cheapest",
+			strategy:          StrategyCheapest,
+			expectedPackSetup: "1x5000",
+			expectedCost:      1000 + 250,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 4001, Strategy: tc.strategy})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if quote.PackSetup != tc.expectedPackSetup {
+				t.Errorf("expected pack setup %q, got %q", tc.expectedPackSetup, quote.PackSetup)
+			}
+			if quote.HandlingCostCents != 250 {
+				t.Errorf("expected handling cost 250, got %d", quote.HandlingCostCents)
+			}
+			if quote.TotalCostCents != tc.expectedCost {
+				t.Errorf("expected total cost %d, got %d", tc.expectedCost, quote.TotalCostCents)
+			}
+			if quote.Lines[0].TotalCostCents != tc.expectedCost-250 {
+				t.Errorf("expected line cost %d, got %d", tc.expectedCost-250, quote.Lines[0].TotalCostCents)
+			}
+		})
+	}
+
+	// Price changes publish a new catalog version
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 4001, Strategy: StrategyCheapest})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	if order.TotalCostCents != 1250 {
+		t.Errorf("expected order total cost 1250, got %d", order.TotalCostCents)
+	}
+
+	for _, ps := range packSizes {
+		if ps.Size == 5000 {
+			cost := 5000
+			if _, err := packSizesService.UpdatePackSize(payload.UpdatePackSize{ID: ps.ID, UnitCostCents: &cost}); err != nil {
+				t.Fatalf("failed to set pack size cost: %v", err)
+			}
+		}
+	}
+
+	recalculation, err := service.RecalculateOrder(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !recalculation.CatalogChanged || !recalculation.PacksChanged {
+		t.Errorf("expected the price change to change the catalog and packs, got %+v", recalculation)
+	}
+	if recalculation.Current.PackSetup != "2x2000, 1x250" {
+		t.Errorf("expected the cheapest packing to change to 2x2000, 1x250, got %q", recalculation.Current.PackSetup)
+	}
+
+	cost := -1
+	if _, err := packSizesService.UpdatePackSize(payload.UpdatePackSize{ID: packSizes[0].ID, UnitCostCents: &cost}); !errors.Is(err, payload.ErrInvalidCost) {
+		t.Errorf("expected ErrInvalidCost, got %v", err)
+	}
+}
+
+func TestOrdersService_QuoteOrderStrategy(t *testing.T) {
+	repo := repositories.NewInMemoryOrdersRepository()
+	packSizesRepo := setupPackSizesRepositoryWithDefaults()
+	defer packSizesRepo.Clear()
+
+	// Deployments can change the default strategy
+	service := NewOrdersService(repo, packSizesRepo, repositories.NewInMemoryProductsRepository(), config.OrdersConfig{
+		DefaultStrategy: StrategyFewestPacks,
+	})
+
+	testCases := []struct {
+		name              string
+		strategy          string
+		expectedStrategy  string
+		expectedPackSetup string
+		expectedError     error
+	}{
+		{
+			name:              "Configured default strategy",
+			expectedStrategy:  StrategyFewestPacks,
+			expectedPackSetup: "1x1000",
+		},
+		{
+			name:              "Strategy picked by the request",
+			strategy:          StrategyFewestItems,
+			expectedStrategy:  StrategyFewestItems,
+			expectedPackSetup: "1x500, 1x250",
+		},
+		{
+			name:              "Greedy strategy",
+			strategy:          StrategyGreedy,
+			expectedStrategy:  StrategyGreedy,
+			expectedPackSetup: "1x500, 1x250",
+		},
+		{
+			name:          "Unknown strategy",
+			strategy:      "random",
+			expectedError: payload.ErrUnknownStrategy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quote, err := service.QuoteOrder(payload.CreateOrder{ItemsCount: 501, Strategy: tc.strategy})
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if quote.Strategy != tc.expectedStrategy {
+				t.Errorf("expected strategy %q, got %q", tc.expectedStrategy, quote.Strategy)
+			}
+			if quote.PackSetup != tc.expectedPackSetup {
+				t.Errorf("expected pack setup %q, got %q", tc.expectedPackSetup, quote.PackSetup)
+			}
+		})
+	}
+
+	// Recalculations keep the strategy the order was created with
+	order, err := service.CreateOrder(payload.CreateOrder{ItemsCount: 501, Strategy: StrategyGreedy})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	recalculation, err := service.RecalculateOrder(order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recalculation.Current.Strategy != StrategyGreedy || recalculation.PacksChanged {
+		t.Errorf("expected an unchanged greedy recalculation, got %+v", recalculation.Current)
+	}
+}
+
+func runStrategyTestCases(t *testing.T, strategy PackingStrategy, testCases []strategyTestCase) {
+	t.Helper()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packSizes := make([]models.PackSize, len(tc.packSizes))
+			for i, size := range tc.packSizes {
+				packSizes[i] = models.PackSize{ID: uuid.New(), Size: size}
+				if stock, tracked := tc.stock[size]; tracked {
+					packSizes[i].Stock = &stock
+				}
+				packSizes[i].UnitCostCents = tc.costs[size]
+			}
+
+			result, err := strategy.Calculate(tc.itemsCount, packSizes)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(result.Packs) != len(tc.expectedPacks) {
+				t.Errorf("expected packs %v, got %v", tc.expectedPacks, result.Packs)
+			}
+			for packSize, expectedCount := range tc.expectedPacks {
+				if result.Packs[packSize] != expectedCount {
+					t.Errorf("pack size %d: expected %d, got %d", packSize, expectedCount, result.Packs[packSize])
+				}
+			}
+
+			totalItems, totalPacks := 0, 0
+			for packSize, count := range result.Packs {
+				totalItems += packSize * count
+				totalPacks += count
+			}
+
+			if result.TotalItems != totalItems || result.TotalPacks != totalPacks {
+				t.Errorf("expected totals of %d items in %d packs, got %d items in %d packs",
+					totalItems, totalPacks, result.TotalItems, result.TotalPacks)
+			}
+			if totalItems < tc.itemsCount {
+				t.Errorf("total items %d is less than required %d", totalItems, tc.itemsCount)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -47,26 +48,79 @@ func main() {
 
 	ordersRepo := repositories.NewOrdersRepository(database)
 	packSizesRepo := repositories.NewPackSizesRepository(database)
+	productsRepo := repositories.NewProductsRepository(database)
+	catalogChangesRepo := repositories.NewCatalogChangesRepository(database)
 
-	ordersService := services.NewOrdersService(ordersRepo, packSizesRepo)
+	ordersService := services.NewOrdersService(ordersRepo, packSizesRepo, productsRepo, cfg.Orders)
 	packSizesService := services.NewPackSizesService(packSizesRepo)
+	productsService := services.NewProductsService(productsRepo)
+	catalogSyncService := services.NewCatalogSyncService(catalogChangesRepo)
+
+	// Changes made on this replica are applied right away and announced to
+	// the others, which apply them as they're notified
+	packSizesService.OnChange(ordersService.InvalidateSolverCache)
+	packSizesService.AnnounceChanges(catalogSyncService.Change)
+
+	go func() {
+		if err := catalogSyncService.Run(context.Background(), ordersService.InvalidateSolverCache); err != nil {
+			log.Printf("Stopped listening for catalog changes: %v", err)
+		}
+	}()
 
 	ordersHandler := handlers.NewOrdersHandler(ordersService)
 	packSizesHandler := handlers.NewPackSizesHandler(packSizesService)
+	productsHandler := handlers.NewProductsHandler(productsService)
 
-	setupRoutes(app, ordersHandler, packSizesHandler)
+	setupRoutes(app, ordersHandler, packSizesHandler, productsHandler)
 
 	if err := app.Listen(fmt.Sprintf(":%d", cfg.Fiber.Port)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
-func setupRoutes(app *fiber.App, ordersHandler *handlers.OrdersHandler, packSizesHandler *handlers.PackSizesHandler) {
+func setupRoutes(
+	app *fiber.App,
+	ordersHandler *handlers.OrdersHandler,
+	packSizesHandler *handlers.PackSizesHandler,
+	productsHandler *handlers.ProductsHandler,
+) {
 	app.Post("/orders", ordersHandler.CreateOrder)
+	app.Post("/orders/bulk", ordersHandler.CreateOrders)
+	app.Post("/orders/import", ordersHandler.ImportOrders)
+	app.Get("/orders/export", ordersHandler.ExportOrders)
 	app.Get("/orders/:order_id", ordersHandler.GetOrder)
-	app.Get("/orders", ordersHandler.GetAllOrders)
+	app.Put("/orders/:order_id", ordersHandler.AmendOrder)
+	app.Delete("/orders/:order_id", ordersHandler.CancelOrder)
+	app.Get("/orders/:order_id/revisions", ordersHandler.GetOrderRevisions)
+	app.Get("/orders/:order_id/recalculation", ordersHandler.RecalculateOrder)
+	app.Patch("/orders/:order_id/status", ordersHandler.UpdateOrderStatus)
+	app.Get("/orders/:order_id/status-history", ordersHandler.GetOrderStatusHistory)
+	app.Get("/orders", ordersHandler.ListOrders)
+
+	app.Post("/quotes", ordersHandler.QuoteOrder)
 
 	app.Post("/pack-sizes", packSizesHandler.CreatePackSize)
+	app.Post("/pack-sizes/import", packSizesHandler.ImportPackSizes)
 	app.Get("/pack-sizes", packSizesHandler.GetAllPackSizes)
+	app.Put("/pack-sizes", packSizesHandler.ReplacePackSizes)
 	app.Put("/pack-sizes/:pack_size_id", packSizesHandler.UpdatePackSize)
+	app.Delete("/pack-sizes/:pack_size_id", packSizesHandler.DeletePackSize)
+	app.Put("/pack-sizes/:pack_size_id/stock", packSizesHandler.SetPackSizeStock)
+	app.Put("/pack-sizes/:pack_size_id/dimensions", packSizesHandler.SetPackSizeDimensions)
+
+	app.Post("/pack-sizes/catalogs", packSizesHandler.ScheduleCatalog)
+	app.Get("/pack-sizes/catalogs", packSizesHandler.GetAllCatalogs)
+
+	app.Post("/products", productsHandler.CreateProduct)
+	app.Get("/products", productsHandler.GetAllProducts)
+	app.Get("/products/:product_id", productsHandler.GetProduct)
+	app.Put("/products/:product_id", productsHandler.UpdateProduct)
+	app.Delete("/products/:product_id", productsHandler.DeleteProduct)
+
+	app.Post("/products/:product_id/pack-sizes", packSizesHandler.CreatePackSize)
+	app.Post("/products/:product_id/pack-sizes/import", packSizesHandler.ImportPackSizes)
+	app.Get("/products/:product_id/pack-sizes", packSizesHandler.GetAllPackSizes)
+	app.Put("/products/:product_id/pack-sizes", packSizesHandler.ReplacePackSizes)
+	app.Post("/products/:product_id/pack-sizes/catalogs", packSizesHandler.ScheduleCatalog)
+	app.Get("/products/:product_id/pack-sizes/catalogs", packSizesHandler.GetAllCatalogs)
 }
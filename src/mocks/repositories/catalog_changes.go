@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// InMemoryCatalogChangesRepository delivers every published change to all
+// listeners, the publisher's own included, like a notification channel
+// shared by the replicas.
+type InMemoryCatalogChangesRepository struct {
+	mu        sync.RWMutex
+	nextID    int
+	listeners map[int]func(change models.CatalogChange)
+}
+
+func NewInMemoryCatalogChangesRepository() *InMemoryCatalogChangesRepository {
+	return &InMemoryCatalogChangesRepository{
+		listeners: make(map[int]func(change models.CatalogChange)),
+	}
+}
+
+func (r *InMemoryCatalogChangesRepository) PublishCatalogChange(change models.CatalogChange) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, listener := range r.listeners {
+		listener(change)
+	}
+
+	return nil
+}
+
+func (r *InMemoryCatalogChangesRepository) ListenCatalogChanges(ctx context.Context, handle func(change models.CatalogChange)) error {
+	r.mu.Lock()
+	id := r.nextID
+	r.nextID++
+	r.listeners[id] = handle
+	r.mu.Unlock()
+
+	<-ctx.Done()
+
+	r.mu.Lock()
+	delete(r.listeners, id)
+	r.mu.Unlock()
+
+	return ctx.Err()
+}
+
+// Listeners returns how many listeners are subscribed.
+func (r *InMemoryCatalogChangesRepository) Listeners() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.listeners)
+}
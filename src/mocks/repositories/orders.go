@@ -1,43 +1,245 @@
 package repositories
 
 import (
+	"bytes"
+	"cmp"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
 )
 
 type InMemoryOrdersRepository struct {
-	mu     sync.RWMutex
-	orders map[string]models.Order
+	mu        sync.RWMutex
+	orders    map[string]models.Order
+	history   map[string][]models.OrderStatusChange
+	revisions map[string][]models.OrderRevision
+	keys      map[string]models.IdempotencyKey
+	packSizes *InMemoryPackSizesRepository
 }
 
 func NewInMemoryOrdersRepository() *InMemoryOrdersRepository {
 	return &InMemoryOrdersRepository{
-		orders: make(map[string]models.Order),
+		orders:    make(map[string]models.Order),
+		history:   make(map[string][]models.OrderStatusChange),
+		revisions: make(map[string][]models.OrderRevision),
+		keys:      make(map[string]models.IdempotencyKey),
 	}
 }
 
-func (r *InMemoryOrdersRepository) GetAllOrders() ([]models.Order, error) {
+// NewInMemoryOrdersRepositoryWithStock returns a repository that takes the
+// packs of saved orders out of the stock of the given pack sizes.
+func NewInMemoryOrdersRepositoryWithStock(packSizes *InMemoryPackSizesRepository) *InMemoryOrdersRepository {
+	repo := NewInMemoryOrdersRepository()
+	repo.packSizes = packSizes
+
+	return repo
+}
+
+func (r *InMemoryOrdersRepository) ListOrders(query payload.ListOrders) ([]models.Order, error) {
+	field, descending := query.SortField()
+	if !slices.Contains(payload.OrderSortFields, field) {
+		return nil, payload.ErrInvalidOrderSort
+	}
+
+	var after any
+	if query.After != nil {
+		value, err := query.After.SortValue()
+		if err != nil {
+			return nil, payload.ErrInvalidCursor
+		}
+		after = value
+	}
+
+	orders := r.filterOrders(query)
+
+	// Orders are compared by the sort field and then by ID, like the
+	// database does
+	compare := func(aValue any, aID uuid.UUID, bValue any, bID uuid.UUID) int {
+		result := compareValues(aValue, bValue)
+		if result == 0 {
+			result = bytes.Compare(aID[:], bID[:])
+		}
+		if descending {
+			result = -result
+		}
+
+		return result
+	}
+
+	slices.SortFunc(orders, func(a, b models.Order) int {
+		return compare(sortValue(a, field), a.ID, sortValue(b, field), b.ID)
+	})
+
+	page := []models.Order{}
+	for _, order := range orders {
+		if query.After != nil && compare(sortValue(order, field), order.ID, after, query.After.ID) <= 0 {
+			continue
+		}
+		if len(page) == query.Limit {
+			break
+		}
+
+		page = append(page, order)
+	}
+
+	return page, nil
+}
+
+func (r *InMemoryOrdersRepository) CountOrders(query payload.ListOrders) (int, error) {
+	return len(r.filterOrders(query)), nil
+}
+
+func (r *InMemoryOrdersRepository) filterOrders(query payload.ListOrders) []models.Order {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	orders := make([]models.Order, 0, len(r.orders))
 	for _, order := range r.orders {
+		if query.MinItemsCount != nil && order.ItemsCount < *query.MinItemsCount {
+			continue
+		}
+		if query.MaxItemsCount != nil && order.ItemsCount > *query.MaxItemsCount {
+			continue
+		}
+		if query.CreatedFrom != nil && order.CreatedAt.Before(*query.CreatedFrom) {
+			continue
+		}
+		if query.CreatedTo != nil && !order.CreatedAt.Before(*query.CreatedTo) {
+			continue
+		}
+		if query.ProductID != nil && !slices.ContainsFunc(order.Lines, func(line models.OrderLine) bool {
+			return line.ProductID == *query.ProductID
+		}) {
+			continue
+		}
+		if query.PackSize != nil && !slices.ContainsFunc(order.Lines, func(line models.OrderLine) bool {
+			return slices.ContainsFunc(line.Packs, func(pack models.OrderPack) bool {
+				return pack.PackSize == *query.PackSize
+			})
+		}) {
+			continue
+		}
+
 		orders = append(orders, order)
 	}
 
-	return orders, nil
+	return orders
+}
+
+func sortValue(order models.Order, field string) any {
+	switch field {
+	case payload.OrderSortItemsCount:
+		return order.ItemsCount
+	case payload.OrderSortShippedItems:
+		return order.ShippedItems
+	case payload.OrderSortTotalPacks:
+		return order.TotalPacks
+	case payload.OrderSortTotalCostCents:
+		return order.TotalCostCents
+	default:
+		return order.CreatedAt
+	}
+}
+
+func compareValues(a, b any) int {
+	if at, ok := a.(time.Time); ok {
+		return at.Compare(b.(time.Time))
+	}
+
+	return cmp.Compare(a.(int), b.(int))
 }
 
 func (r *InMemoryOrdersRepository) SaveOrder(order models.Order) (models.Order, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.packSizes != nil {
+		if err := r.packSizes.takeStock(packQuantities(order)); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	r.orders[order.ID.String()] = order
+	return order, nil
+}
+
+func (r *InMemoryOrdersRepository) SaveOrders(orders []models.Order) ([]models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.packSizes != nil {
+		quantities := make(map[uuid.UUID]int)
+		for _, order := range orders {
+			for id, quantity := range packQuantities(order) {
+				quantities[id] += quantity
+			}
+		}
+
+		if err := r.packSizes.takeStock(quantities); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, order := range orders {
+		r.orders[order.ID.String()] = order
+	}
+
+	return orders, nil
+}
+
+func (r *InMemoryOrdersRepository) SaveOrderWithIdempotencyKey(order models.Order, key models.IdempotencyKey) (models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.keys[key.Key]; exists && existing.ExpiresAt.After(key.CreatedAt) {
+		return models.Order{}, &pgconn.PgError{Code: "23505"}
+	}
+
+	if r.packSizes != nil {
+		if err := r.packSizes.takeStock(packQuantities(order)); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	// The response is stored as JSON, so later changes of the order don't
+	// reach it
+	if key.Response != nil {
+		encoded, err := json.Marshal(key.Response)
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		key.Response = &models.Order{}
+		if err := json.Unmarshal(encoded, key.Response); err != nil {
+			return models.Order{}, err
+		}
+	}
+
 	r.orders[order.ID.String()] = order
+	r.keys[key.Key] = key
 	return order, nil
 }
 
+func (r *InMemoryOrdersRepository) FetchIdempotencyKey(key string, at time.Time) (models.IdempotencyKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	existing, exists := r.keys[key]
+	if !exists || !existing.ExpiresAt.After(at) {
+		return models.IdempotencyKey{}, sql.ErrNoRows
+	}
+
+	return existing, nil
+}
+
 func (r *InMemoryOrdersRepository) FetchOrder(orderID string) (models.Order, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -50,12 +252,106 @@ func (r *InMemoryOrdersRepository) FetchOrder(orderID string) (models.Order, err
 	return order, nil
 }
 
+func (r *InMemoryOrdersRepository) UpdateOrderStatus(change models.OrderStatusChange) (models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, exists := r.orders[change.OrderID.String()]
+	if !exists {
+		return models.Order{}, sql.ErrNoRows
+	}
+
+	if order.Status != change.FromStatus {
+		return models.Order{}, payload.ErrInvalidStatusTransition
+	}
+
+	order.Status = change.ToStatus
+	order.UpdatedAt = change.ChangedAt
+	r.orders[order.ID.String()] = order
+	r.history[order.ID.String()] = append(r.history[order.ID.String()], change)
+
+	if change.ToStatus == models.OrderStatusCancelled && r.packSizes != nil {
+		r.packSizes.returnStock(packQuantities(order))
+	}
+
+	return order, nil
+}
+
+func (r *InMemoryOrdersRepository) AmendOrder(order models.Order, revision models.OrderRevision) (models.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.orders[order.ID.String()]
+	if !exists {
+		return models.Order{}, sql.ErrNoRows
+	}
+
+	if !current.UpdatedAt.Equal(revision.Previous.UpdatedAt) {
+		return models.Order{}, payload.ErrOrderChanged
+	}
+
+	if r.packSizes != nil {
+		r.packSizes.returnStock(packQuantities(current))
+
+		if err := r.packSizes.takeStock(packQuantities(order)); err != nil {
+			// Nothing is saved, so the previous packs are taken again
+			_ = r.packSizes.takeStock(packQuantities(current))
+
+			return models.Order{}, err
+		}
+	}
+
+	order.Status = current.Status
+	order.Strategy = current.Strategy
+	order.CreatedAt = current.CreatedAt
+	r.orders[order.ID.String()] = order
+
+	revision.Revision = len(r.revisions[order.ID.String()]) + 1
+	r.revisions[order.ID.String()] = append(r.revisions[order.ID.String()], revision)
+
+	return order, nil
+}
+
+func (r *InMemoryOrdersRepository) GetOrderRevisions(orderID string) ([]models.OrderRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := make([]models.OrderRevision, len(r.revisions[orderID]))
+	copy(revisions, r.revisions[orderID])
+
+	return revisions, nil
+}
+
+func packQuantities(order models.Order) map[uuid.UUID]int {
+	quantities := make(map[uuid.UUID]int)
+	for _, line := range order.Lines {
+		for _, pack := range line.Packs {
+			quantities[pack.PackSizeID] += pack.Quantity
+		}
+	}
+
+	return quantities
+}
+
+func (r *InMemoryOrdersRepository) GetOrderStatusHistory(orderID string) ([]models.OrderStatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := make([]models.OrderStatusChange, len(r.history[orderID]))
+	copy(history, r.history[orderID])
+
+	return history, nil
+}
+
 // Helper method for testing - clear all orders
 func (r *InMemoryOrdersRepository) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	r.orders = make(map[string]models.Order)
+	r.history = make(map[string][]models.OrderStatusChange)
+	r.revisions = make(map[string][]models.OrderRevision)
+	r.keys = make(map[string]models.IdempotencyKey)
 }
 
 // Helper method for testing - get count of orders
@@ -1,14 +1,21 @@
 package repositories
 
 import (
+	"database/sql"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/payload"
 )
 
 type InMemoryPackSizesRepository struct {
-	mu        sync.RWMutex
-	packSizes map[string]models.PackSize
+	mu            sync.RWMutex
+	packSizes     map[string]models.PackSize
+	catalogs      []models.PackSizeCatalog
+	notifications *InMemoryCatalogChangesRepository
 }
 
 func NewInMemoryPackSizesRepository() *InMemoryPackSizesRepository {
@@ -21,34 +28,248 @@ func (r *InMemoryPackSizesRepository) CreatePackSize(packSize models.PackSize) (
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// New pack sizes start active, like the column default
+	packSize.Active = true
+
 	r.packSizes[packSize.ID.String()] = packSize
 	return packSize, nil
 }
 
-func (r *InMemoryPackSizesRepository) GetAllPackSizes() ([]models.PackSize, error) {
+func (r *InMemoryPackSizesRepository) GetAllPackSizes(productID string) ([]models.PackSize, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	packSizes := make([]models.PackSize, 0, len(r.packSizes))
 	for _, packSize := range r.packSizes {
-		packSizes = append(packSizes, packSize)
+		if packSize.ProductID.String() == productID {
+			packSizes = append(packSizes, packSize)
+		}
 	}
 
 	return packSizes, nil
 }
 
-func (r *InMemoryPackSizesRepository) UpdatePackSize(packSize models.PackSize) (models.PackSize, error) {
+func (r *InMemoryPackSizesRepository) GetPackSize(packSizeID string) (models.PackSize, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	packSize, exists := r.packSizes[packSizeID]
+	if !exists {
+		return models.PackSize{}, sql.ErrNoRows
+	}
+
+	return packSize, nil
+}
+
+func (r *InMemoryPackSizesRepository) SetPackSizeStock(packSizeID string, stock *int) (models.PackSize, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.packSizes[packSize.ID.String()] = packSize
+	packSize, exists := r.packSizes[packSizeID]
+	if !exists {
+		return models.PackSize{}, sql.ErrNoRows
+	}
+
+	packSize.Stock = stock
+	r.packSizes[packSizeID] = packSize
 	return packSize, nil
 }
 
+// takeStock takes the given quantities out of stock, all or nothing, like
+// the stock update in the orders transaction.
+func (r *InMemoryPackSizesRepository) takeStock(quantities map[uuid.UUID]int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for packSizeID, quantity := range quantities {
+		packSize, exists := r.packSizes[packSizeID.String()]
+		if !exists || (packSize.Stock != nil && *packSize.Stock < quantity) {
+			return payload.ErrInsufficientStock
+		}
+	}
+
+	for packSizeID, quantity := range quantities {
+		packSize := r.packSizes[packSizeID.String()]
+		if packSize.Stock != nil {
+			stock := *packSize.Stock - quantity
+			packSize.Stock = &stock
+			r.packSizes[packSizeID.String()] = packSize
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryPackSizesRepository) returnStock(quantities map[uuid.UUID]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for packSizeID, quantity := range quantities {
+		packSize, exists := r.packSizes[packSizeID.String()]
+		if exists && packSize.Stock != nil {
+			stock := *packSize.Stock + quantity
+			packSize.Stock = &stock
+			r.packSizes[packSizeID.String()] = packSize
+		}
+	}
+}
+
+func (r *InMemoryPackSizesRepository) GetAllCatalogs(productID string) ([]models.PackSizeCatalog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	catalogs := make([]models.PackSizeCatalog, 0, len(r.catalogs))
+	for _, catalog := range r.catalogs {
+		if catalog.ProductID.String() == productID {
+			catalogs = append(catalogs, catalog)
+		}
+	}
+
+	// Newest first, keeping creation order for catalogs with the same date
+	sort.SliceStable(catalogs, func(i, j int) bool {
+		return catalogs[i].EffectiveFrom.After(catalogs[j].EffectiveFrom)
+	})
+
+	return catalogs, nil
+}
+
+func (r *InMemoryPackSizesRepository) GetCatalogAt(productID string, at time.Time) (models.PackSizeCatalog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found := false
+	var current models.PackSizeCatalog
+	for _, catalog := range r.catalogs {
+		if catalog.ProductID.String() != productID || catalog.EffectiveFrom.After(at) {
+			continue
+		}
+
+		if !found || !catalog.EffectiveFrom.Before(current.EffectiveFrom) {
+			current = catalog
+			found = true
+		}
+	}
+
+	if !found {
+		return models.PackSizeCatalog{}, sql.ErrNoRows
+	}
+
+	return current, nil
+}
+
+// SendNotificationsTo delivers the notifications of the changes to the
+// listeners of the given repository.
+func (r *InMemoryPackSizesRepository) SendNotificationsTo(notifications *InMemoryCatalogChangesRepository) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = notifications
+}
+
+// ChangePackSizes holds the lock while the change is made, so changes are
+// made one after the other like with the product lock of the SQL
+// repository. The notification is delivered once the change is saved, like
+// one sent in the transaction.
+func (r *InMemoryPackSizesRepository) ChangePackSizes(
+	productID uuid.UUID,
+	change func(models.ProductPackSizes) (models.PackSizeChanges, error),
+) (models.PackSizeChanges, error) {
+	saved, notifications, err := r.changePackSizes(productID, change)
+	if err != nil {
+		return models.PackSizeChanges{}, err
+	}
+
+	if saved.Notification != nil && notifications != nil {
+		if err := notifications.PublishCatalogChange(*saved.Notification); err != nil {
+			return models.PackSizeChanges{}, err
+		}
+	}
+
+	return saved, nil
+}
+
+func (r *InMemoryPackSizesRepository) changePackSizes(
+	productID uuid.UUID,
+	change func(models.ProductPackSizes) (models.PackSizeChanges, error),
+) (models.PackSizeChanges, *InMemoryCatalogChangesRepository, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := time.Now()
+	state := models.ProductPackSizes{At: at, PackSizes: []models.PackSize{}}
+	for _, packSize := range r.packSizes {
+		if packSize.ProductID == productID {
+			state.PackSizes = append(state.PackSizes, packSize)
+		}
+	}
+	sort.Slice(state.PackSizes, func(i, j int) bool {
+		return state.PackSizes[i].Size < state.PackSizes[j].Size
+	})
+
+	for _, catalog := range r.catalogs {
+		if catalog.ProductID != productID {
+			continue
+		}
+
+		if catalog.EffectiveFrom.After(at) {
+			state.Pending = append(state.Pending, catalog)
+		} else if state.Current == nil || !catalog.EffectiveFrom.Before(state.Current.EffectiveFrom) {
+			current := catalog
+			state.Current = &current
+		}
+	}
+	sort.SliceStable(state.Pending, func(i, j int) bool {
+		return state.Pending[i].EffectiveFrom.Before(state.Pending[j].EffectiveFrom)
+	})
+
+	changes, err := change(state)
+	if err != nil {
+		return models.PackSizeChanges{}, nil, err
+	}
+
+	for _, packSizeID := range changes.Deleted {
+		delete(r.packSizes, packSizeID.String())
+	}
+
+	for i, packSize := range changes.Updated {
+		existing, exists := r.packSizes[packSize.ID.String()]
+		if !exists {
+			return models.PackSizeChanges{}, nil, sql.ErrNoRows
+		}
+
+		// Stock is left as it is, like the SQL update
+		packSize.Stock = existing.Stock
+		r.packSizes[packSize.ID.String()] = packSize
+		changes.Updated[i] = packSize
+	}
+
+	for _, packSize := range changes.Created {
+		r.packSizes[packSize.ID.String()] = packSize
+	}
+
+	for i, catalog := range changes.Catalogs {
+		catalog.CreatedAt = time.Now()
+		r.catalogs = append(r.catalogs, catalog)
+		changes.Catalogs[i] = catalog
+	}
+
+	for i, pending := range changes.Pending {
+		for j, catalog := range r.catalogs {
+			if catalog.ID == pending.ID {
+				r.catalogs[j].PackSizes = pending.PackSizes
+				changes.Pending[i] = r.catalogs[j]
+			}
+		}
+	}
+
+	return changes, r.notifications, nil
+}
+
 // Helper method for testing - clear all pack sizes
 func (r *InMemoryPackSizesRepository) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	r.packSizes = make(map[string]models.PackSize)
+	r.catalogs = nil
 }
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+type InMemoryProductsRepository struct {
+	mu       sync.RWMutex
+	products map[string]models.Product
+}
+
+// NewInMemoryProductsRepository starts with the default product, the same
+// way the products migration seeds it.
+func NewInMemoryProductsRepository() *InMemoryProductsRepository {
+	return &InMemoryProductsRepository{
+		products: map[string]models.Product{
+			models.DefaultProductID.String(): {
+				ID:        models.DefaultProductID,
+				Name:      "Default",
+				SKU:       "DEFAULT",
+				CreatedAt: time.Now(),
+			},
+		},
+	}
+}
+
+func (r *InMemoryProductsRepository) GetAllProducts() ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+func (r *InMemoryProductsRepository) FetchProduct(productID string) (models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, exists := r.products[productID]
+	if !exists {
+		return models.Product{}, sql.ErrNoRows
+	}
+
+	return product, nil
+}
+
+func (r *InMemoryProductsRepository) CreateProduct(product models.Product) (models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.skuTaken(product) {
+		return models.Product{}, &pgconn.PgError{Code: "23505"}
+	}
+
+	product.CreatedAt = time.Now()
+	r.products[product.ID.String()] = product
+	return product, nil
+}
+
+func (r *InMemoryProductsRepository) UpdateProduct(product models.Product) (models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.products[product.ID.String()]
+	if !exists {
+		return models.Product{}, sql.ErrNoRows
+	}
+
+	if r.skuTaken(product) {
+		return models.Product{}, &pgconn.PgError{Code: "23505"}
+	}
+
+	existing.Name = product.Name
+	existing.SKU = product.SKU
+	r.products[product.ID.String()] = existing
+	return existing, nil
+}
+
+// skuTaken tells whether another product has the SKU, like the unique
+// constraint on the column.
+func (r *InMemoryProductsRepository) skuTaken(product models.Product) bool {
+	for _, existing := range r.products {
+		if existing.ID != product.ID && existing.SKU == product.SKU {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *InMemoryProductsRepository) DeleteProduct(productID string) (models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, exists := r.products[productID]
+	if !exists {
+		return models.Product{}, sql.ErrNoRows
+	}
+
+	delete(r.products, productID)
+	return product, nil
+}
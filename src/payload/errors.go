@@ -3,7 +3,52 @@ package payload
 import "errors"
 
 var (
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound            = errors.New("order not found")
+	ErrProductNotFound          = errors.New("product not found")
+	ErrProductInUse             = errors.New("product is referenced by orders")
+	ErrDefaultProduct           = errors.New("the default product can't be deleted")
+	ErrMissingProductFields     = errors.New("name and sku are required")
+	ErrProductFieldsTooLong     = errors.New("the name can't be longer than 255 characters and the sku than 64")
+	ErrDuplicateSKU             = errors.New("another product already has this sku")
+	ErrCatalogEffectiveFromPast = errors.New("catalog effective date is in the past")
+	ErrAmbiguousOrderLines      = errors.New("an order takes either lines or a single items count, not both")
+	ErrPackSizeNotFound         = errors.New("pack size not found")
+	ErrInvalidPackSizeStatus    = errors.New("invalid pack size status, expected active, inactive or all")
+	ErrInvalidStock             = errors.New("stock can't be negative")
+	ErrInsufficientStock        = errors.New("the items can't be packed with the packs in stock")
+	ErrUnknownStrategy          = errors.New("unknown packing strategy, expected fewest-items, fewest-packs, greedy or cheapest")
+	ErrInvalidCost              = errors.New("unit cost can't be negative")
+	ErrInvalidWeight            = errors.New("weight can't be negative")
+	ErrInvalidLength            = errors.New("length can't be negative")
+	ErrInvalidWidth             = errors.New("width can't be negative")
+	ErrInvalidHeight            = errors.New("height can't be negative")
+	ErrNoPackSizes              = errors.New("there are no pack sizes to pack the items with")
+	ErrTooManyItems             = errors.New("the order is over the maximum items count")
+	ErrTooManyBoundedItems      = errors.New("the line is over the maximum items count for packing with tracked stock or by cost")
+	ErrPackTooHeavy             = errors.New("every pack size is heavier than the maximum shipment weight")
+	ErrInvalidOrderSort         = errors.New("invalid sort, expected created_at, items_count, shipped_items, total_packs or total_cost_cents, optionally prefixed with -")
+	ErrInvalidOrderStatus       = errors.New("invalid status, expected quoted, confirmed, packed, shipped or cancelled")
+	ErrInvalidStatusTransition  = errors.New("the order can't move from its current status to the requested one")
+	ErrMissingChangedBy         = errors.New("changed_by is required")
+	ErrOrderNotAmendable        = errors.New("the order can't be changed once it's shipped or cancelled")
+	ErrAmendLines               = errors.New("an order with several lines is amended with its lines, not a single items count")
+	ErrInvalidItemsCount        = errors.New("items count must be positive")
+	ErrOrderChanged             = errors.New("the order was changed by someone else, try again")
+	ErrInvalidIdempotencyKey    = errors.New("the idempotency key can't be longer than 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("the idempotency key was already used with a different request")
+	ErrInvalidBulkMode          = errors.New("invalid mode, expected atomic or partial")
+	ErrEmptyBulk                = errors.New("there are no orders to create")
+	ErrTooManyBulkOrders        = errors.New("too many orders, at most 10000 are created at once")
+	ErrInvalidExportFormat      = errors.New("invalid format, expected csv or xlsx")
+	ErrInvalidExportRows        = errors.New("invalid rows, expected orders or packs")
+	ErrInvalidPackSize          = errors.New("size must be positive")
+	ErrEmptyImport              = errors.New("the CSV has no rows to import")
+	ErrTooManyImportRows        = errors.New("too many rows, at most 10000 are imported at once")
+	ErrNoSizes                  = errors.New("the catalog needs at least one pack size")
+	ErrTooManySizes             = errors.New("too many pack sizes, a catalog has at most 100")
+	ErrDuplicateSize            = errors.New("the pack sizes must be unique")
+	ErrInactivePackSize         = errors.New("the catalog can't include an inactive pack size, activate it first")
+	ErrInvalidCursor            = errors.New("invalid cursor, expected the next cursor of a page with the same sort")
 )
 
 type ErrorResponse struct {
@@ -0,0 +1,31 @@
+package payload
+
+import "github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+
+// ImportRowError is a problem with a row of an imported CSV. Rows are
+// numbered like the lines of the file, the header being row 1, and Column
+// is left empty when the problem isn't with a single column. Err is the
+// error the row failed with, which the handler turns into Message when the
+// service didn't set one.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+	Err     error  `json:"-" swaggerignore:"true"`
+}
+
+// PackSizesImport is the outcome of a pack sizes import, which either
+// creates every pack size or, when any row has errors, none of them.
+type PackSizesImport struct {
+	Imported  int               `json:"imported"`
+	PackSizes []models.PackSize `json:"pack_sizes"`
+	Errors    []ImportRowError  `json:"errors"`
+}
+
+// OrdersImport is the outcome of an orders import, which either creates
+// every order or, when any row has errors, none of them.
+type OrdersImport struct {
+	Imported int              `json:"imported"`
+	Orders   []models.Order   `json:"orders"`
+	Errors   []ImportRowError `json:"errors"`
+}
@@ -1,5 +1,178 @@
 package payload
 
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// CreateOrder packs every line with the pack sizes of its product. Orders of
+// a single product can still be sent as product_id and items_count, which
+// is treated as an order with one line. Lines without a product ID use the
+// default product. Strategy picks the packing strategy, falling back to the
+// configured default when empty. Explain comes from the explain query
+// parameter rather than the body and asks for the alternatives every line's
+// packing was weighed against.
 type CreateOrder struct {
-	ItemsCount int `json:"items_count" validate:"required,gt=0"`
+	ProductID      uuid.UUID   `json:"product_id"`
+	ItemsCount     int         `json:"items_count" validate:"required_without=Lines,omitempty,gt=0"`
+	Lines          []OrderLine `json:"lines" validate:"omitempty,dive"`
+	Strategy       string      `json:"strategy" validate:"omitempty,oneof=fewest-items fewest-packs greedy cheapest"`
+	Explain        bool        `json:"-" swaggerignore:"true"`
+	IdempotencyKey string      `json:"-" swaggerignore:"true"`
+}
+
+type OrderLine struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	ItemsCount int       `json:"items_count" validate:"required,gt=0"`
+}
+
+// UpdateOrderStatus moves an order to another status. ChangedBy names who
+// made the change and is kept in the order's status history.
+type UpdateOrderStatus struct {
+	Status    models.OrderStatus `json:"status" validate:"required,oneof=quoted confirmed packed shipped cancelled"`
+	ChangedBy string             `json:"changed_by" validate:"required"`
+}
+
+// AmendOrder changes the items count of an order, which is packed again
+// with the current catalog and the order's strategy. Single line orders
+// take items_count and keep their product, while orders with several lines
+// take the new lines. ChangedBy is kept with the previous breakdown.
+type AmendOrder struct {
+	ItemsCount int         `json:"items_count" validate:"required_without=Lines,omitempty,gt=0"`
+	Lines      []OrderLine `json:"lines" validate:"omitempty,dive"`
+	ChangedBy  string      `json:"changed_by" validate:"required"`
+}
+
+// Bulk creation modes: atomic creates every order of the batch or none of
+// them, partial creates the orders it can and reports the others.
+const (
+	BulkModeAtomic  = "atomic"
+	BulkModePartial = "partial"
+)
+
+// Statuses of an order of a bulk creation. Skipped orders were fine but
+// weren't created because another order of an atomic batch failed.
+const (
+	BulkOrderCreated = "created"
+	BulkOrderFailed  = "failed"
+	BulkOrderSkipped = "skipped"
+)
+
+type CreateOrders struct {
+	Orders []CreateOrder
+	Mode   string
+}
+
+// BulkOrderResult is the outcome of the order at Index, counted from 0, of
+// a bulk creation. Err is the error the order failed with, which the
+// handler turns into Error.
+type BulkOrderResult struct {
+	Index  int           `json:"index"`
+	Status string        `json:"status"`
+	Order  *models.Order `json:"order,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Err    error         `json:"-" swaggerignore:"true"`
+}
+
+type BulkOrdersResult struct {
+	Mode    string            `json:"mode"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []BulkOrderResult `json:"results"`
+}
+
+type OrderRecalculation struct {
+	Order          models.Order `json:"order"`
+	Current        Quote        `json:"current"`
+	CatalogChanged bool         `json:"catalog_changed"`
+	PacksChanged   bool         `json:"packs_changed"`
+}
+
+const (
+	OrderSortCreatedAt      = "created_at"
+	OrderSortItemsCount     = "items_count"
+	OrderSortShippedItems   = "shipped_items"
+	OrderSortTotalPacks     = "total_packs"
+	OrderSortTotalCostCents = "total_cost_cents"
+)
+
+// OrderSortFields are the fields orders can be sorted by.
+var OrderSortFields = []string{
+	OrderSortCreatedAt,
+	OrderSortItemsCount,
+	OrderSortShippedItems,
+	OrderSortTotalPacks,
+	OrderSortTotalCostCents,
+}
+
+// ListOrders filters, sorts and paginates the orders. Sort is one of the
+// OrderSortFields, prefixed with "-" for descending order, and ties are
+// broken by ID. Cursor is the next cursor of the previous page, which the
+// service decodes into After, and only works with the sort it was made
+// with. The items count range is inclusive while the created range
+// excludes its end. ProductID and PackSize match the orders with a line of
+// the product or a pack of the size.
+type ListOrders struct {
+	Cursor        string
+	After         *OrderCursor
+	Limit         int
+	Sort          string
+	MinItemsCount *int
+	MaxItemsCount *int
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	ProductID     *uuid.UUID
+	PackSize      *int
+}
+
+// SortField returns the field to sort by and whether the order is
+// descending.
+func (q ListOrders) SortField() (string, bool) {
+	return strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+}
+
+// OrderCursor points at the last order of a page by its value of the sort
+// field, formatted as RFC 3339 for created_at, and its ID.
+type OrderCursor struct {
+	Sort  string    `json:"sort"`
+	Value string    `json:"value"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// SortValue parses the cursor's value as the type of its sort field.
+func (c OrderCursor) SortValue() (any, error) {
+	if strings.TrimPrefix(c.Sort, "-") == OrderSortCreatedAt {
+		return time.Parse(time.RFC3339Nano, c.Value)
+	}
+
+	return strconv.Atoi(c.Value)
+}
+
+// Export formats and rows: an orders export has a row per order, a packs
+// export a row per pack size of every order line.
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+	ExportRowsOrders = "orders"
+	ExportRowsPacks  = "packs"
+)
+
+// ExportOrders exports every order matching the filters of Query, which is
+// sorted like a list of orders but not paginated.
+type ExportOrders struct {
+	Query  ListOrders
+	Format string
+	Rows   string
+}
+
+// OrdersPage is a page of orders. NextCursor is nil on the last page and
+// TotalCount counts the orders matching the filters across all pages.
+type OrdersPage struct {
+	Orders     []models.Order `json:"orders"`
+	NextCursor *string        `json:"next_cursor"`
+	TotalCount int            `json:"total_count"`
 }
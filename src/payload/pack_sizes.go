@@ -1,12 +1,60 @@
 package payload
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CreatePackSize struct {
-	Size int `json:"size" validate:"required,gt=0"`
+	Size          int  `json:"size" validate:"required,gt=0"`
+	Stock         *int `json:"stock" validate:"omitempty,gte=0"`
+	UnitCostCents int  `json:"unit_cost_cents" validate:"gte=0"`
+	WeightGrams   int  `json:"weight_grams" validate:"gte=0"`
+	LengthMm      int  `json:"length_mm" validate:"gte=0"`
+	WidthMm       int  `json:"width_mm" validate:"gte=0"`
+	HeightMm      int  `json:"height_mm" validate:"gte=0"`
 }
 
+// UpdatePackSize changes the size, the active flag and/or the unit cost,
+// leaving out whichever isn't given.
 type UpdatePackSize struct {
-	ID   uuid.UUID `json:"id" validate:"required"`
-	Size int       `json:"size" validate:"required,gt=0"`
+	ID            uuid.UUID `json:"id"`
+	Size          int       `json:"size" validate:"omitempty,gt=0"`
+	Active        *bool     `json:"active"`
+	UnitCostCents *int      `json:"unit_cost_cents" validate:"omitempty,gte=0"`
+}
+
+// UpdatePackSizeStock sets the packs in stock, a null stock stops tracking
+// it so the pack size is always available.
+type UpdatePackSizeStock struct {
+	Stock *int `json:"stock" validate:"omitempty,gte=0"`
+}
+
+// UpdatePackSizeDimensions sets the weight and outer dimensions of a single
+// pack.
+type UpdatePackSizeDimensions struct {
+	WeightGrams int `json:"weight_grams" validate:"gte=0"`
+	LengthMm    int `json:"length_mm" validate:"gte=0"`
+	WidthMm     int `json:"width_mm" validate:"gte=0"`
+	HeightMm    int `json:"height_mm" validate:"gte=0"`
+}
+
+type PackSizeStatus string
+
+const (
+	PackSizeStatusActive   PackSizeStatus = "active"
+	PackSizeStatusInactive PackSizeStatus = "inactive"
+	PackSizeStatusAll      PackSizeStatus = "all"
+)
+
+type ScheduleCatalog struct {
+	EffectiveFrom time.Time `json:"effective_from" validate:"required"`
+	Sizes         []int     `json:"sizes" validate:"required,min=1,dive,gt=0"`
+}
+
+// ReplacePackSizes is the full set of sizes a product's catalog is replaced
+// with.
+type ReplacePackSizes struct {
+	Sizes []int `json:"sizes" validate:"required,min=1,max=100,dive,gt=0"`
 }
@@ -0,0 +1,11 @@
+package payload
+
+type CreateProduct struct {
+	Name string `json:"name" validate:"required"`
+	SKU  string `json:"sku" validate:"required"`
+}
+
+type UpdateProduct struct {
+	Name string `json:"name" validate:"required"`
+	SKU  string `json:"sku" validate:"required"`
+}
@@ -0,0 +1,46 @@
+package payload
+
+import (
+	"github.com/google/uuid"
+	"github.com/luk3skyw4lker/order-pack-calculator/src/database/models"
+)
+
+// Quote mirrors the order it would create: totals are summed over the
+// lines, the handling cost of every shipment is added to the total cost and
+// the product, breakdown and catalog are only set at the top level for
+// single line quotes.
+type Quote struct {
+	ProductID         *uuid.UUID                 `json:"product_id"`
+	ItemsCount        int                        `json:"items_count"`
+	PackSetup         string                     `json:"pack_setup"`
+	Packs             []models.OrderPack         `json:"packs"`
+	ShippedItems      int                        `json:"shipped_items"`
+	Overshoot         int                        `json:"overshoot"`
+	TotalPacks        int                        `json:"total_packs"`
+	Catalog           []models.PackSize          `json:"catalog"`
+	CatalogID         *uuid.UUID                 `json:"catalog_id"`
+	Strategy          string                     `json:"strategy"`
+	HandlingCostCents int                        `json:"handling_cost_cents"`
+	TotalCostCents    int                        `json:"total_cost_cents"`
+	TotalWeightGrams  int                        `json:"total_weight_grams"`
+	TotalVolumeMm3    int                        `json:"total_volume_mm3"`
+	Shipments         []models.Shipment          `json:"shipments"`
+	Lines             []QuoteLine                `json:"lines"`
+	Explanation       *models.PackingExplanation `json:"explanation,omitempty"`
+}
+
+type QuoteLine struct {
+	ProductID        uuid.UUID                  `json:"product_id"`
+	ItemsCount       int                        `json:"items_count"`
+	PackSetup        string                     `json:"pack_setup"`
+	Packs            []models.OrderPack         `json:"packs"`
+	ShippedItems     int                        `json:"shipped_items"`
+	Overshoot        int                        `json:"overshoot"`
+	TotalPacks       int                        `json:"total_packs"`
+	Catalog          []models.PackSize          `json:"catalog"`
+	CatalogID        *uuid.UUID                 `json:"catalog_id"`
+	TotalCostCents   int                        `json:"total_cost_cents"`
+	TotalWeightGrams int                        `json:"total_weight_grams"`
+	TotalVolumeMm3   int                        `json:"total_volume_mm3"`
+	Explanation      *models.PackingExplanation `json:"explanation,omitempty"`
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/swagger/v2"
@@ -17,6 +20,21 @@ func UnmarshalRequest[T any](ctx fiber.Ctx) (T, error) {
 	return payload, nil
 }
 
+// UploadedFile returns the file uploaded in the given field of a multipart
+// form, or the body of the request when it isn't a form.
+func UploadedFile(ctx fiber.Ctx, field string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return io.NopCloser(bytes.NewReader(ctx.Body())), nil
+	}
+
+	header, err := ctx.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+
+	return header.Open()
+}
+
 func InitDocs(router *fiber.App) {
 	router.Get("/", func(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusMovedPermanently).Redirect().To("/swagger/index.html")